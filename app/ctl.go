@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/agorman/resync"
+	"github.com/namsral/flag"
+)
+
+const ctlUsage = `Usage: resync ctl [-conf path] <command> [name]
+
+Commands:
+  list           List every sync and its status
+  run <name>     Run a sync now
+  cancel <name>  Cancel a running sync
+  pause <name>   Stop a sync's cron job from running it
+  resume <name>  Let a paused sync's cron job run it again
+  tail <name>    Follow the STDOUT and STDERR of a running sync until it finishes
+  changes <name> [path]
+                 List the paths changed by each stored run of a sync with manifest enabled.
+                 If path is set then only the runs that changed path are listed.
+`
+
+// ctl talks to a running resync through its control socket.
+func ctl(args []string) error {
+	fs := flag.NewFlagSet("ctl", flag.ExitOnError)
+	conf := fs.String("conf", "/etc/resync/resync.yaml", "Path to the resync configuration file")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, ctlUsage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := resync.OpenConfig(*conf)
+	if err != nil {
+		return err
+	}
+
+	client := resync.NewSocketClient(config)
+	ctx := context.Background()
+
+	if fs.NArg() == 1 && fs.Arg(0) == "list" {
+		statuses, err := client.Statuses(ctx)
+		if err != nil {
+			return err
+		}
+		return printStatuses(statuses, resync.StringValue(config.TimeFormat))
+	}
+
+	if fs.NArg() == 3 && fs.Arg(0) == "changes" {
+		manifests, err := client.Manifests(ctx, fs.Arg(1), fs.Arg(2), "")
+		if err != nil {
+			return err
+		}
+		return printManifests(manifests)
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("Invalid ctl command")
+	}
+
+	name := fs.Arg(1)
+	switch fs.Arg(0) {
+	case "run":
+		started, err := client.Run(ctx, name)
+		if err != nil {
+			return err
+		}
+		if !started {
+			return fmt.Errorf("Skipped %s because it's already running", name)
+		}
+		fmt.Printf("Started %s\n", name)
+	case "cancel":
+		cancelled, err := client.Cancel(ctx, name)
+		if err != nil {
+			return err
+		}
+		if !cancelled {
+			return fmt.Errorf("%s isn't running", name)
+		}
+		fmt.Printf("Cancelled %s\n", name)
+	case "pause":
+		if err := client.Pause(ctx, name); err != nil {
+			return err
+		}
+		fmt.Printf("Paused %s\n", name)
+	case "resume":
+		if err := client.Resume(ctx, name); err != nil {
+			return err
+		}
+		fmt.Printf("Resumed %s\n", name)
+	case "tail":
+		return tailSync(client, name)
+	case "changes":
+		manifests, err := client.Manifests(ctx, name, "", "")
+		if err != nil {
+			return err
+		}
+		return printManifests(manifests)
+	default:
+		fs.Usage()
+		return fmt.Errorf("Invalid ctl command: %s", fs.Arg(0))
+	}
+
+	return nil
+}
+
+// tailSync copies the STDOUT and STDERR of the running sync name to os.Stdout and os.Stderr until it finishes.
+func tailSync(client *resync.Client, name string) error {
+	tailc := make(chan error, 2)
+	go func() {
+		tailc <- client.Tail(context.Background(), name, "stdout", os.Stdout)
+	}()
+	go func() {
+		tailc <- client.Tail(context.Background(), name, "stderr", os.Stderr)
+	}()
+
+	for i := 0; i < 2; i++ {
+		if err := <-tailc; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printStatuses(statuses []resync.SyncStatus, format string) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+
+	fmt.Fprintln(writer, "NAME\tSCHEDULE\tRUNNING\tQUEUED\tPROGRESS\tPAUSED\tNEXT\tLAST START\tLAST STATUS")
+	for _, status := range statuses {
+		next := ""
+		if !status.Next.IsZero() {
+			next = status.Next.Format(format)
+		}
+
+		lastStart, lastStatus := "", ""
+		if status.LastStat != nil {
+			lastStart = status.LastStat.Start
+			lastStatus = string(status.LastStat.Status)
+		}
+
+		schedule := status.Schedule
+		if schedule == "" {
+			schedule = "after " + strings.Join(status.DependsOn, ",")
+		}
+
+		progress := ""
+		if status.Progress != nil {
+			progress = fmt.Sprintf("%d%% %s ETA %s", status.Progress.Percent, status.Progress.Bytes, status.Progress.ETA)
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%t\t%t\t%s\t%t\t%s\t%s\t%s\n", status.Name, schedule, status.Running, status.Queued, progress, status.Paused, next, lastStart, lastStatus)
+	}
+
+	return writer.Flush()
+}
+
+func printManifests(manifests []resync.Manifest) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+
+	fmt.Fprintln(writer, "START\tCHANGE\tITEM\tPATH")
+	for _, manifest := range manifests {
+		for _, entry := range manifest.Entries {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", manifest.Start, entry.Change, entry.Item, entry.Path)
+		}
+	}
+
+	return writer.Flush()
+}
+
+// printStats prints the stats from the running resync through the control socket. If resync isn't running then the
+// stats are read from the database instead.
+func printStats(config *resync.Config) error {
+	if resync.IntValue(config.Retention) < 1 {
+		return errors.New("Unable to print stats when retention is less than 1")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := resync.NewSocketClient(config).Stats(ctx)
+	if err == nil {
+		return resync.WriteStats(os.Stdout, stats)
+	}
+
+	db, err := resync.NewBoltDB(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stats, err = db.List()
+	if err != nil {
+		return err
+	}
+
+	return resync.WriteStats(os.Stdout, stats)
+}
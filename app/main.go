@@ -1,26 +1,31 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"syscall"
-	"time"
 
 	"github.com/agorman/resync"
-	"github.com/etherlabsio/healthcheck/v2"
 	"github.com/namsral/flag"
 	log "github.com/sirupsen/logrus"
 	lumberjack "gopkg.in/natefinch/lumberjack.v2"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "ctl" {
+		if err := ctl(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	conf := flag.String("conf", "/etc/resync/resync.yaml", "Path to the resync configuration file")
 	stats := flag.Bool("stats", false, "Print sync stats and exit")
 	debug := flag.Bool("debug", false, "Log to STDOUT")
+	tail := flag.String("tail", "", "Follow the STDOUT and STDERR of a running sync and exit when it finishes")
 	flag.Parse()
 
 	config, err := resync.OpenConfig(*conf)
@@ -28,6 +33,20 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if *tail != "" {
+		if err := tailSync(resync.NewSocketClient(config), *tail); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *stats {
+		if err := printStats(config); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if !*debug {
 		logfile := &lumberjack.Logger{
 			Filename:   filepath.Join(resync.StringValue(config.LogPath), "resync.log"),
@@ -49,67 +68,45 @@ func main() {
 
 	re := resync.New(config, db, logger, notifier)
 
-	if *stats {
-		if err := re.Dump(); err != nil {
-			log.Fatal(err)
-		}
-		return
-	}
-
 	if err := re.Start(); err != nil {
 		log.Fatal(err)
 	}
 
-	errc := make(chan error, 1)
+	errc := make(chan error, 2)
 	sigc := make(chan os.Signal, 1)
 	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
 
-	// get most recent status for each sync and if any have failed then return an error
+	server := resync.NewServer(config, re, db, logger)
+	defer server.Close()
+
+	go func() {
+		errc <- server.ServeSocket(resync.SocketPath(config))
+	}()
+
 	if config.HTTP != nil {
-		http.Handle("/live", healthcheck.Handler(
-			healthcheck.WithTimeout(5*time.Second),
-			healthcheck.WithChecker(
-				"live", healthcheck.CheckerFunc(
-					func(ctx context.Context) error {
-						return nil
-					},
-				),
-			),
-		))
-
-		http.Handle("/health", healthcheck.Handler(
-			healthcheck.WithTimeout(5*time.Second),
-			healthcheck.WithChecker(
-				"health", healthcheck.CheckerFunc(
-					func(ctx context.Context) error {
-						statMap, err := db.List()
-						if err != nil {
-							return err
-						}
-
-						for name, stats := range statMap {
-							if len(stats) > 0 && !stats[0].Success {
-								return fmt.Errorf("One more more syncs failed including %s", name)
-							}
-						}
-
-						return nil
-					},
-				),
-			),
-		))
+		addr := fmt.Sprintf("%s:%d", resync.StringValue(config.HTTP.Addr), resync.IntValue(config.HTTP.Port))
 
 		go func() {
-			errc <- http.ListenAndServe(fmt.Sprintf("%s:%d", resync.StringValue(config.HTTP.Addr), resync.IntValue(config.HTTP.Port)), nil)
+			if config.HTTP.TLS() {
+				errc <- http.ListenAndServeTLS(addr, resync.StringValue(config.HTTP.CertFile), resync.StringValue(config.HTTP.KeyFile), server)
+			} else {
+				errc <- http.ListenAndServe(addr, server)
+			}
 		}()
 	}
 
+	wait(re, sigc, errc)
+}
+
+// wait blocks until a signal is received on sigc or a server fails on errc. It then stops re so running syncs and
+// hooks are sent SIGTERM and given their grace period to exit before resync does.
+func wait(re *resync.Resync, sigc <-chan os.Signal, errc <-chan error) {
 	select {
 	case s := <-sigc:
 		log.Warnf("Received signal %s, exiting", s)
-		return
 	case e := <-errc:
 		log.Errorf("Run error: %s", e)
-		return
 	}
+
+	re.Stop()
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/agorman/resync"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitStopsSyncs(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// sh stands in for rsync and exits as soon as it's sent SIGTERM
+	conf := filepath.Join(dir, "resync.yaml")
+	err = os.WriteFile(conf, []byte(fmt.Sprintf(`
+rsync_path: sh
+lib_path: %s
+log_path: %s
+retention: 1
+grace_period: 5s
+syncs:
+  test:
+    rsync_args: -c
+    rsync_source:
+      - trap 'exit 20' TERM; sleep 10 & wait
+    rsync_destination: dest
+    schedule: "0 0 1 1 *"
+`, dir, dir)), 0600)
+	assert.Nil(t, err)
+
+	config, err := resync.OpenConfig(conf)
+	assert.Nil(t, err)
+
+	db, err := resync.NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := resync.NewFSLogger(config)
+	re := resync.New(config, db, logger, resync.NewEmailNotifier(config, db, logger))
+
+	err = re.Start()
+	assert.Nil(t, err)
+
+	started, err := re.Run("test")
+	assert.Nil(t, err)
+	assert.True(t, started)
+
+	assert.Eventually(t, func() bool {
+		status, err := re.Status("test")
+		return err == nil && status.Running
+	}, 5*time.Second, 10*time.Millisecond)
+	// give sh time to set its trap
+	time.Sleep(200 * time.Millisecond)
+
+	sigc := make(chan os.Signal, 1)
+	sigc <- syscall.SIGTERM
+
+	start := time.Now()
+	wait(re, sigc, make(chan error))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["test"], 1)
+	assert.Equal(t, resync.StatusCancelled, stats["test"][0].Status)
+	assert.Equal(t, resync.EndShutdown, stats["test"][0].EndReason)
+	assert.Equal(t, 20, stats["test"][0].ExitCode)
+}
@@ -0,0 +1,82 @@
+package resync
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync/atomic"
+	"time"
+)
+
+// bandwidthLimitRegexp matches the values rsync accepts for --bwlimit like 500, 1.5M, or 2MiB.
+var bandwidthLimitRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([KMGTPkmgtp](i?[Bb])?)?$`)
+
+// bandwidthClock returns the time used to choose bandwidth limits. Tests replace it to cross schedule boundaries.
+var bandwidthClock = time.Now
+
+// Bandwidth is a bandwidth limit used while a sync starts inside a window. Bandwidth has the same options as Window
+// along with Limit.
+type Bandwidth struct {
+	Window `yaml:",inline"`
+
+	// Limit is passed to rsync as --bwlimit. Limit is in KiB per second unless it has a suffix like M. A limit of 0
+	// is unlimited.
+	Limit *string `yaml:"limit"`
+}
+
+// validate checks the window and the limit.
+func (b *Bandwidth) validate() error {
+	if err := b.Window.validate(); err != nil {
+		return err
+	}
+
+	if b.Limit == nil {
+		return fmt.Errorf("Bandwidth requires a limit")
+	}
+
+	if !bandwidthLimitRegexp.MatchString(StringValue(b.Limit)) {
+		return fmt.Errorf("Invalid bandwidth limit %s", StringValue(b.Limit))
+	}
+
+	return nil
+}
+
+// bandwidthAt returns the limit of the first entry in bandwidth that contains t. It returns false if none of them do.
+func bandwidthAt(bandwidth []*Bandwidth, t time.Time) (string, bool) {
+	for _, b := range bandwidth {
+		if b.Contains(t) {
+			return StringValue(b.Limit), true
+		}
+	}
+	return "", false
+}
+
+// watchBandwidth returns a context that's cancelled once the bandwidth limit of sync is no longer limit so that rsync
+// can be restarted with the new limit. The returned function stops watching and returns true if the limit changed.
+func watchBandwidth(ctx context.Context, sync *Sync, limit string) (context.Context, func() bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	var changed int32
+
+	go func() {
+		ticker := time.NewTicker(windowCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if l, _ := sync.BandwidthAt(bandwidthClock()); l != limit {
+					atomic.StoreInt32(&changed, 1)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, func() bool {
+		cancel()
+		return atomic.LoadInt32(&changed) == 1
+	}
+}
@@ -0,0 +1,103 @@
+package resync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestBandwidth(t *testing.T) {
+	// Mon Jan 01 2024
+	monday := func(hour, min int) time.Time {
+		return time.Date(2024, 1, 1, hour, min, 0, 0, time.Local)
+	}
+
+	bandwidth := []*Bandwidth{}
+	err := yaml.Unmarshal([]byte(`
+- days: [mon-fri]
+  start: "08:00"
+  end: "18:00"
+  limit: 2M
+- start: "18:00"
+  end: "20:00"
+  limit: "500"
+`), &bandwidth)
+	assert.Nil(t, err)
+	assert.Len(t, bandwidth, 2)
+
+	for _, b := range bandwidth {
+		assert.Nil(t, b.validate())
+	}
+
+	limit, ok := bandwidthAt(bandwidth, monday(12, 0))
+	assert.True(t, ok)
+	assert.Equal(t, "2M", limit)
+
+	limit, ok = bandwidthAt(bandwidth, monday(19, 0))
+	assert.True(t, ok)
+	assert.Equal(t, "500", limit)
+
+	_, ok = bandwidthAt(bandwidth, monday(7, 59))
+	assert.False(t, ok)
+
+	_, ok = bandwidthAt(bandwidth, monday(12, 0).AddDate(0, 0, -1))
+	assert.False(t, ok)
+}
+
+func TestBandwidthValidate(t *testing.T) {
+	valid := []string{"0", "500", "1.5M", "2m", "100K", "1G", "2MB", "2MiB"}
+	for _, limit := range valid {
+		b := &Bandwidth{Window: Window{Start: String("08:00"), End: String("18:00")}, Limit: String(limit)}
+		assert.Nil(t, b.validate(), limit)
+	}
+
+	invalid := []*Bandwidth{
+		{Window: Window{Start: String("08:00"), End: String("18:00")}},
+		{Window: Window{Start: String("08:00"), End: String("18:00")}, Limit: String("fast")},
+		{Window: Window{Start: String("08:00"), End: String("18:00")}, Limit: String("-1")},
+		{Window: Window{Start: String("08:00"), End: String("18:00")}, Limit: String("2X")},
+		{Window: Window{Start: String("08:00")}, Limit: String("2M")},
+	}
+	for _, b := range invalid {
+		assert.Error(t, b.validate())
+	}
+}
+
+func TestWatchBandwidth(t *testing.T) {
+	sync := &Sync{
+		Bandwidth: []*Bandwidth{
+			{Window: Window{Start: String("00:00"), End: String("00:00")}, Limit: String("2M")},
+		},
+	}
+	assert.Nil(t, sync.Bandwidth[0].validate())
+
+	// the limit is always 2M so the watch only ends when it's stopped
+	ctx, restarted := watchBandwidth(context.Background(), sync, "2M")
+	select {
+	case <-ctx.Done():
+		t.Fatal("context cancelled while the limit didn't change")
+	case <-time.After(windowCheckInterval + 500*time.Millisecond):
+	}
+	assert.False(t, restarted())
+	assert.Error(t, ctx.Err())
+
+	// the limit the watch started with isn't the current limit
+	ctx, restarted = watchBandwidth(context.Background(), sync, "1M")
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context wasn't cancelled after the limit changed")
+	}
+	assert.True(t, restarted())
+
+	// cancelling the parent isn't a limit change
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, restarted = watchBandwidth(parent, sync, "1M")
+	cancel()
+	<-ctx.Done()
+	time.Sleep(windowCheckInterval + 500*time.Millisecond)
+	assert.False(t, restarted())
+}
@@ -0,0 +1,226 @@
+package resync
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Client talks to the HTTP server of a running resync. If the server requires authentication then the client uses
+// the first configured token or user.
+type Client struct {
+	url    string
+	client *http.Client
+	token  string
+	user   string
+	pass   string
+}
+
+// NewClient creates a Client for the HTTP server defined in config. An error is returned if the HTTP server isn't
+// configured.
+func NewClient(config *Config) (*Client, error) {
+	if config.HTTP == nil {
+		return nil, errors.New("Client: the http server isn't configured")
+	}
+
+	// connect to localhost when the server listens on all addresses
+	addr := StringValue(config.HTTP.Addr)
+	if ip := net.ParseIP(addr); ip != nil && ip.IsUnspecified() {
+		addr = "localhost"
+	}
+
+	c := &Client{
+		url:    "http://" + net.JoinHostPort(addr, strconv.Itoa(IntValue(config.HTTP.Port))),
+		client: &http.Client{},
+	}
+
+	if config.HTTP.TLS() {
+		// trust the server's certificate in case it's self signed
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		cert, err := os.ReadFile(StringValue(config.HTTP.CertFile))
+		if err != nil {
+			return nil, fmt.Errorf("Client: failed to read cert_file: %w", err)
+		}
+		pool.AppendCertsFromPEM(cert)
+
+		c.url = "https://" + strings.TrimPrefix(c.url, "http://")
+		c.client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs: pool,
+			},
+		}
+	}
+
+	if len(config.HTTP.Tokens) > 0 {
+		c.token = config.HTTP.Tokens[0]
+	} else {
+		// use the first user sorted by name so the choice is consistent
+		users := make([]string, 0, len(config.HTTP.Users))
+		for user := range config.HTTP.Users {
+			users = append(users, user)
+		}
+		sort.Strings(users)
+
+		if len(users) > 0 {
+			c.user = users[0]
+			c.pass = config.HTTP.Users[users[0]]
+		}
+	}
+
+	return c, nil
+}
+
+// NewSocketClient creates a Client that connects to the control socket in the configured lib path.
+func NewSocketClient(config *Config) *Client {
+	path := SocketPath(config)
+
+	return &Client{
+		// the host is ignored when dialing the socket
+		url: "http://resync",
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+	}
+}
+
+// Statuses returns the status of every configured sync sorted by name.
+func (c *Client) Statuses(ctx context.Context) ([]SyncStatus, error) {
+	statuses := make([]SyncStatus, 0)
+	return statuses, c.doJSON(ctx, http.MethodGet, "/syncs", &statuses)
+}
+
+// Stats returns all of the stored stats. The map keys are sync names and the values are sorted by Start in
+// descending order.
+func (c *Client) Stats(ctx context.Context) (map[string][]Stat, error) {
+	stats := make(map[string][]Stat)
+	return stats, c.doJSON(ctx, http.MethodGet, "/stats", &stats)
+}
+
+// Run starts the sync with name. It returns false if the sync was skipped because it's already running.
+func (c *Client) Run(ctx context.Context, name string) (bool, error) {
+	result := RunResult{}
+	err := c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/syncs/%s/run", url.PathEscape(name)), &result, http.StatusConflict)
+	return result.Started, err
+}
+
+// Cancel cancels the running sync with name. It returns false if the sync wasn't running.
+func (c *Client) Cancel(ctx context.Context, name string) (bool, error) {
+	result := CancelResult{}
+	err := c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/syncs/%s/cancel", url.PathEscape(name)), &result, http.StatusConflict)
+	return result.Cancelled, err
+}
+
+// Pause stops the cron job for the sync with name from running the sync until Resume is called.
+func (c *Client) Pause(ctx context.Context, name string) error {
+	return c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/syncs/%s/pause", url.PathEscape(name)), &PauseResult{})
+}
+
+// Resume lets the cron job for the paused sync with name run the sync again.
+func (c *Client) Resume(ctx context.Context, name string) error {
+	return c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/syncs/%s/resume", url.PathEscape(name)), &PauseResult{})
+}
+
+// Manifests returns the stored manifests for the sync with name sorted by Start in descending order. If path or change
+// are set then only the runs that changed path are returned.
+func (c *Client) Manifests(ctx context.Context, name string, path string, change Change) ([]Manifest, error) {
+	query := url.Values{}
+	if path != "" {
+		query.Set("path", path)
+	}
+	if change != "" {
+		query.Set("change", string(change))
+	}
+
+	manifests := make([]Manifest, 0)
+	return manifests, c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/syncs/%s/manifests?%s", url.PathEscape(name), query.Encode()), &manifests)
+}
+
+// Tail writes the log for the running sync with name to w until the sync finishes or ctx is done. Stream must be
+// either stdout or stderr.
+func (c *Client) Tail(ctx context.Context, name string, stream string, w io.Writer) error {
+	resp, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/syncs/%s/tail?stream=%s", url.PathEscape(name), url.QueryEscape(stream)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(w, resp.Body); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("Client: failed to read %s for %s: %w", stream, name, err)
+	}
+	return nil
+}
+
+// doJSON sends a request to the server and decodes the JSON response body into v. Responses with a status code in
+// codes are decoded the same as 2xx responses.
+func (c *Client) doJSON(ctx context.Context, method string, path string, v interface{}, codes ...int) error {
+	resp, err := c.do(ctx, method, path, codes...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("Client: failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// do sends a request to the server and returns the response if the status code is 2xx or in codes. Otherwise the
+// error from the response body is returned.
+func (c *Client) do(ctx context.Context, method string, path string, codes ...int) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Client: failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.user != "" {
+		req.SetBasicAuth(c.user, c.pass)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Client: request failed: %w", err)
+	}
+
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+
+		body := struct {
+			Error string
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+			return nil, fmt.Errorf("Client: unexpected response: %s", resp.Status)
+		}
+		return nil, fmt.Errorf("Client: %s", body.Error)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,204 @@
+package resync
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient returns a Client for a test http server.
+func newTestClient(t *testing.T, server *Server) (*Client, func()) {
+	ts := httptest.NewServer(server)
+
+	host, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	assert.Nil(t, err)
+
+	p, err := strconv.Atoi(port)
+	assert.Nil(t, err)
+
+	client, err := NewClient(&Config{
+		HTTP: &HTTP{
+			Addr: String(host),
+			Port: Int(p),
+		},
+	})
+	assert.Nil(t, err)
+
+	return client, ts.Close
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient(&Config{})
+	assert.Error(t, err)
+
+	client, err := NewClient(&Config{
+		HTTP: &HTTP{
+			Addr: String("0.0.0.0"),
+			Port: Int(4050),
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:4050", client.url)
+}
+
+func TestClientTail(t *testing.T) {
+	re, cleanup := newTestResync(t, newTailConfig())
+	defer cleanup()
+
+	client, closeServer := newTestClient(t, NewServer(re.config, re, re.db, re.logger))
+	defer closeServer()
+
+	err := client.Tail(context.Background(), "test", "stdout", &bytes.Buffer{})
+	assert.Error(t, err)
+
+	err = client.Tail(context.Background(), "test", "foo", &bytes.Buffer{})
+	assert.Error(t, err)
+
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	stdout := &bytes.Buffer{}
+	err = client.Tail(context.Background(), "test", "stdout", stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "one\nthree\n", stdout.String())
+}
+
+func TestClientAuth(t *testing.T) {
+	re, cleanup := newTestResync(t, newTailConfig())
+	defer cleanup()
+
+	client, closeServer := newTestClient(t, NewServer(re.config, re, re.db, re.logger))
+	defer closeServer()
+
+	re.config.HTTP = &HTTP{
+		Users: map[string]string{"me": "pass"},
+	}
+
+	// unauthorized because the client wasn't created with the user
+	err := client.Tail(context.Background(), "test", "stdout", &bytes.Buffer{})
+	assert.EqualError(t, err, "Client: Unauthorized")
+
+	client.user = "me"
+	client.pass = "pass"
+	err = client.Tail(context.Background(), "test", "stdout", &bytes.Buffer{})
+	assert.EqualError(t, err, "Client: Sync isn't running")
+
+	re.config.HTTP = &HTTP{
+		Tokens: []string{"token"},
+	}
+
+	client.token = "token"
+	err = client.Tail(context.Background(), "test", "stdout", &bytes.Buffer{})
+	assert.EqualError(t, err, "Client: Sync isn't running")
+}
+
+func TestNewClientAuth(t *testing.T) {
+	client, err := NewClient(&Config{
+		HTTP: &HTTP{
+			Addr:   String("127.0.0.1"),
+			Port:   Int(4050),
+			Tokens: []string{"token1", "token2"},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "token1", client.token)
+
+	client, err = NewClient(&Config{
+		HTTP: &HTTP{
+			Addr:  String("127.0.0.1"),
+			Port:  Int(4050),
+			Users: map[string]string{"b": "pass2", "a": "pass1"},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "a", client.user)
+	assert.Equal(t, "pass1", client.pass)
+
+	_, err = NewClient(&Config{
+		HTTP: &HTTP{
+			Addr:     String("127.0.0.1"),
+			Port:     Int(4050),
+			CertFile: String("./testdata/missing.pem"),
+			KeyFile:  String("./testdata/missing.pem"),
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestClientTLS(t *testing.T) {
+	re, cleanup := newTestResync(t, newTailConfig())
+	defer cleanup()
+
+	ts := httptest.NewTLSServer(NewServer(re.config, re, re.db, re.logger))
+	defer ts.Close()
+
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600)
+	assert.Nil(t, err)
+
+	_, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	assert.Nil(t, err)
+
+	p, err := strconv.Atoi(port)
+	assert.Nil(t, err)
+
+	// the httptest certificate is valid for 127.0.0.1
+	client, err := NewClient(&Config{
+		HTTP: &HTTP{
+			Addr:     String("127.0.0.1"),
+			Port:     Int(p),
+			CertFile: String(certFile),
+			KeyFile:  String(certFile),
+		},
+	})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(client.url, "https://"))
+
+	err = client.Tail(context.Background(), "test", "stdout", &bytes.Buffer{})
+	assert.EqualError(t, err, "Client: Sync isn't running")
+}
+
+func TestClientManifests(t *testing.T) {
+	re, cleanup := newTestResync(t, newTailConfig())
+	defer cleanup()
+
+	client, closeServer := newTestClient(t, NewServer(re.config, re, re.db, re.logger))
+	defer closeServer()
+
+	manifest := NewManifest(NewStat("test", ""))
+	manifest.Entries = append(manifest.Entries,
+		ManifestEntry{Change: ChangeDeleted, Path: "data/x", Item: "*deleting"},
+		ManifestEntry{Change: ChangeCreated, Path: "data/y", Item: ">f+++++++++"},
+	)
+	err := re.db.InsertManifest(manifest)
+	assert.Nil(t, err)
+
+	manifests, err := client.Manifests(context.Background(), "test", "", "")
+	assert.Nil(t, err)
+	assert.Len(t, manifests, 1)
+	assert.Len(t, manifests[0].Entries, 2)
+
+	manifests, err = client.Manifests(context.Background(), "test", "data/x", ChangeDeleted)
+	assert.Nil(t, err)
+	assert.Len(t, manifests, 1)
+	assert.Equal(t, "data/x", manifests[0].Entries[0].Path)
+
+	_, err = client.Manifests(context.Background(), "foo", "", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package resync
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// runCommand starts cmd and waits for it to exit. If ctx is done first then cmd is sent SIGTERM so it can clean up
+// and is only killed if it's still running after grace. Commands are killed right away if grace is 0 or if they
+// can't be sent SIGTERM, which is always the case on Windows.
+func runCommand(ctx context.Context, cmd *exec.Cmd, grace time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	donec := make(chan error, 1)
+	go func() {
+		donec <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-donec:
+		return err
+	case <-ctx.Done():
+	}
+
+	if grace <= 0 || cmd.Process.Signal(syscall.SIGTERM) != nil {
+		cmd.Process.Kill()
+		return <-donec
+	}
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case err := <-donec:
+		return err
+	case <-timer.C:
+		log.Warnf("Killing %s because it's still running %s after SIGTERM", cmd.Path, grace)
+		cmd.Process.Kill()
+		return <-donec
+	}
+}
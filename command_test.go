@@ -0,0 +1,54 @@
+package resync
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommand(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cleanup := filepath.Join(dir, "cleanup")
+
+	err = runCommand(context.Background(), exec.Command("sh", "-c", "exit 3"), time.Second)
+	assert.Equal(t, 3, exitCode(err))
+
+	// the command can clean up after SIGTERM
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = runCommand(ctx, exec.Command("sh", "-c", "trap 'touch "+cleanup+"; exit 20' TERM; while true; do sleep 0.01; done"), 5*time.Second)
+	assert.Equal(t, 20, exitCode(err))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.FileExists(t, cleanup)
+
+	// the command is killed if it ignores SIGTERM
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start = time.Now()
+	err = runCommand(ctx, exec.Command("sh", "-c", "trap '' TERM; while true; do sleep 0.01; done"), 200*time.Millisecond)
+	assert.Equal(t, -1, exitCode(err))
+	assert.EqualError(t, err, "signal: killed")
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+
+	// a grace period of 0 kills the command right away
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err = runCommand(ctx, exec.Command("sleep", "5"), 0)
+	assert.EqualError(t, err, "signal: killed")
+
+	// the command isn't started if ctx is already done
+	err = runCommand(ctx, exec.Command("sleep", "5"), time.Second)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
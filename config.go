@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -45,10 +46,55 @@ type Config struct {
 	// time limit.
 	TimeLimit *string `yaml:"time_limit"`
 
-	HTTP      *HTTP            `yaml:"http"`
-	Email     *Email           `yaml:"email"`
-	Syncs     map[string]*Sync `yaml:"syncs"`
-	timeLimit time.Duration
+	// Retries is the number of times a failed sync is run again before giving up. Retries happen within the same
+	// time limit as the first attempt. Defaults to 0.
+	Retries *int `yaml:"retries"`
+
+	// RetryBackoff is how long to wait before the first retry. The wait doubles after each retry up to an hour.
+	// RetryBackoff must be a string that can be passed to the time.Duration.ParseDuration() function. Defaults to 30s.
+	RetryBackoff *string `yaml:"retry_backoff"`
+
+	// MaxConcurrent is the maximum number of syncs that run at the same time. Extra runs wait in a queue ordered by
+	// priority. Defaults to 0 which doesn't limit the number of syncs.
+	MaxConcurrent *int `yaml:"max_concurrent"`
+
+	// MaxConcurrentPerDestination is the maximum number of syncs with the same destination host that run at the
+	// same time. Local destinations aren't limited. Defaults to 0 which doesn't limit the number of syncs.
+	MaxConcurrentPerDestination *int `yaml:"max_concurrent_per_destination"`
+
+	// Jitter is the maximum random delay added each time a sync's cron job fires so that hosts sharing the same
+	// schedule don't all start at once. Jitter must be a string that can be passed to the
+	// time.Duration.ParseDuration() function. Defaults to 0s.
+	Jitter *string `yaml:"jitter"`
+
+	// GracePeriod is how long rsync has to exit after it's sent SIGTERM because it was cancelled, timed out, or
+	// resync is stopping. It's killed if it's still running after GracePeriod. GracePeriod must be a string that can
+	// be passed to the time.Duration.ParseDuration() function. Defaults to 10s.
+	GracePeriod *string `yaml:"grace_period"`
+
+	// HookTimeout is the maximum amount of time a hook can run before it's sent SIGTERM and then killed after
+	// GracePeriod. HookTimeout must be a string that can be passed to the time.Duration.ParseDuration() function.
+	// Defaults to 10m.
+	HookTimeout *string `yaml:"hook_timeout"`
+
+	// Windows are the times when syncs are allowed to start. Defaults to any time.
+	Windows []*Window `yaml:"windows"`
+
+	// Blackout are the times when syncs aren't allowed to start even if they're inside one of the Windows.
+	Blackout []*Window `yaml:"blackout"`
+
+	// WindowClose is what happens to a running sync when its window closes or its blackout starts. Valid actions are
+	// finish and cancel. Defaults to finish.
+	WindowClose *string `yaml:"window_close"`
+
+	HTTP         *HTTP            `yaml:"http"`
+	Email        *Email           `yaml:"email"`
+	Syncs        map[string]*Sync `yaml:"syncs"`
+	timeLimit    time.Duration
+	retryBackoff time.Duration
+	jitter       time.Duration
+	gracePeriod  time.Duration
+	hookTimeout  time.Duration
 }
 
 // GetSync returns the Sync object by name. Otherwise it returns an error.
@@ -77,6 +123,114 @@ func (c *Config) GetTimeLimit(name string) (time.Duration, error) {
 	return c.timeLimit, fmt.Errorf("time_limit undefined for %s and no global time_limit is set", name)
 }
 
+// GetRetries returns the number of retries for name if it exists. Otherwise it returns the global retries.
+func (c *Config) GetRetries(name string) int {
+	if sync, err := c.GetSync(name); err == nil {
+		if sync.Retries != nil {
+			return IntValue(sync.Retries)
+		}
+	}
+
+	return IntValue(c.Retries)
+}
+
+// GetRetryBackoff returns the retry backoff for name if it exists. Otherwise it returns the global retry backoff.
+func (c *Config) GetRetryBackoff(name string) time.Duration {
+	if sync, err := c.GetSync(name); err == nil {
+		if sync.RetryBackoff != nil {
+			return sync.retryBackoff
+		}
+	}
+
+	return c.retryBackoff
+}
+
+// GetJitter returns the jitter for name if it exists. Otherwise it returns the global jitter.
+func (c *Config) GetJitter(name string) time.Duration {
+	if sync, err := c.GetSync(name); err == nil {
+		if sync.Jitter != nil {
+			return sync.jitter
+		}
+	}
+
+	return c.jitter
+}
+
+// GetGracePeriod returns the grace period for name if it exists. Otherwise it returns the global grace period.
+func (c *Config) GetGracePeriod(name string) time.Duration {
+	if sync, err := c.GetSync(name); err == nil {
+		if sync.GracePeriod != nil {
+			return sync.gracePeriod
+		}
+	}
+
+	return c.gracePeriod
+}
+
+// GetHookTimeout returns the hook timeout for name if it exists. Otherwise it returns the global hook timeout.
+func (c *Config) GetHookTimeout(name string) time.Duration {
+	if sync, err := c.GetSync(name); err == nil {
+		if sync.HookTimeout != nil {
+			return sync.hookTimeout
+		}
+	}
+
+	return c.hookTimeout
+}
+
+// GetWindowClose returns the window close action for name if it exists. Otherwise it returns the global window close
+// action.
+func (c *Config) GetWindowClose(name string) string {
+	if sync, err := c.GetSync(name); err == nil {
+		if sync.WindowClose != nil {
+			return StringValue(sync.WindowClose)
+		}
+	}
+
+	return StringValue(c.WindowClose)
+}
+
+// Allowed returns true if the sync with name is allowed to start at t. The sync's windows and blackout override the
+// global ones when they're set.
+func (c *Config) Allowed(name string, t time.Time) bool {
+	windows, blackout := c.Windows, c.Blackout
+	if sync, err := c.GetSync(name); err == nil {
+		if sync.Windows != nil {
+			windows = sync.Windows
+		}
+
+		if sync.Blackout != nil {
+			blackout = sync.Blackout
+		}
+	}
+
+	if len(windows) > 0 && !inWindows(windows, t) {
+		return false
+	}
+
+	return !inWindows(blackout, t)
+}
+
+// validateWindows validates windows and the window close action.
+func validateWindows(windows []*Window, blackout []*Window, windowClose *string) error {
+	for _, w := range append(append([]*Window{}, windows...), blackout...) {
+		if w == nil {
+			return errors.New("Window can't be empty")
+		}
+
+		if err := w.validate(); err != nil {
+			return err
+		}
+	}
+
+	switch StringValue(windowClose) {
+	case WindowCloseFinish, WindowCloseCancel:
+		return nil
+	default:
+		return fmt.Errorf("Invalid window_close %s", StringValue(windowClose))
+	}
+}
+
 // validate both validates the configuration and sets the default options.
 func (c *Config) validate() error {
 	if c.RsyncPath == nil {
@@ -140,6 +294,91 @@ func (c *Config) validate() error {
 		}
 	}
 
+	if c.Retries == nil {
+		c.Retries = Int(0)
+	}
+
+	if IntValue(c.Retries) < 0 {
+		return errors.New("retries can't be negative")
+	}
+
+	if c.RetryBackoff == nil {
+		c.RetryBackoff = String("30s")
+	}
+
+	var err error
+	c.retryBackoff, err = time.ParseDuration(StringValue(c.RetryBackoff))
+	if err != nil {
+		return err
+	}
+
+	if c.retryBackoff < 0 {
+		return errors.New("retry_backoff can't be negative")
+	}
+
+	if c.Jitter == nil {
+		c.Jitter = String("0s")
+	}
+
+	c.jitter, err = time.ParseDuration(StringValue(c.Jitter))
+	if err != nil {
+		return err
+	}
+
+	if c.jitter < 0 {
+		return errors.New("jitter can't be negative")
+	}
+
+	if c.GracePeriod == nil {
+		c.GracePeriod = String("10s")
+	}
+
+	c.gracePeriod, err = time.ParseDuration(StringValue(c.GracePeriod))
+	if err != nil {
+		return err
+	}
+
+	if c.gracePeriod < 0 {
+		return errors.New("grace_period can't be negative")
+	}
+
+	if c.HookTimeout == nil {
+		c.HookTimeout = String("10m")
+	}
+
+	c.hookTimeout, err = time.ParseDuration(StringValue(c.HookTimeout))
+	if err != nil {
+		return err
+	}
+
+	if c.hookTimeout <= 0 {
+		return errors.New("hook_timeout must be greater than 0")
+	}
+
+	if c.WindowClose == nil {
+		c.WindowClose = String(WindowCloseFinish)
+	}
+
+	if err := validateWindows(c.Windows, c.Blackout, c.WindowClose); err != nil {
+		return err
+	}
+
+	if c.MaxConcurrent == nil {
+		c.MaxConcurrent = Int(0)
+	}
+
+	if IntValue(c.MaxConcurrent) < 0 {
+		return errors.New("max_concurrent can't be negative")
+	}
+
+	if c.MaxConcurrentPerDestination == nil {
+		c.MaxConcurrentPerDestination = Int(0)
+	}
+
+	if IntValue(c.MaxConcurrentPerDestination) < 0 {
+		return errors.New("max_concurrent_per_destination can't be negative")
+	}
+
 	if c.HTTP != nil {
 		if c.HTTP.Addr == nil {
 			c.HTTP.Addr = String("127.0.0.1")
@@ -148,6 +387,22 @@ func (c *Config) validate() error {
 		if c.HTTP.Port == nil {
 			c.HTTP.Port = Int(4050)
 		}
+
+		if (c.HTTP.CertFile == nil) != (c.HTTP.KeyFile == nil) {
+			return errors.New("Both cert_file and key_file are required to enable http tls")
+		}
+
+		for _, token := range c.HTTP.Tokens {
+			if token == "" {
+				return errors.New("Empty http token")
+			}
+		}
+
+		for user, pass := range c.HTTP.Users {
+			if pass == "" {
+				return fmt.Errorf("Missing password for http user: %s", user)
+			}
+		}
 	}
 
 	if c.Email != nil {
@@ -191,6 +446,10 @@ func (c *Config) validate() error {
 		if c.Email.OnFailure == nil {
 			c.Email.OnFailure = Bool(false)
 		}
+
+		if c.Email.OnSkip == nil {
+			c.Email.OnSkip = Bool(false)
+		}
 	}
 
 	if len(c.Syncs) == 0 {
@@ -198,10 +457,24 @@ func (c *Config) validate() error {
 	}
 
 	for name, sync := range c.Syncs {
-		if sync.Schedule == nil {
+		if strings.HasPrefix(name, reservedPrefix) {
+			return fmt.Errorf("Sync names can't start with %s: %s", reservedPrefix, name)
+		}
+
+		if sync.Schedule == nil && len(sync.DependsOn) == 0 {
 			return fmt.Errorf("Missing schedule entry for sync: %s", name)
 		}
 
+		if sync.Schedule != nil && len(sync.DependsOn) > 0 {
+			return fmt.Errorf("Sync can't have both a schedule and depends_on: %s", name)
+		}
+
+		for _, dep := range sync.DependsOn {
+			if _, ok := c.Syncs[dep]; !ok {
+				return fmt.Errorf("Unknown sync %s in depends_on for sync: %s", dep, name)
+			}
+		}
+
 		if sync.TimeLimit != nil {
 			var err error
 			sync.timeLimit, err = time.ParseDuration(StringValue(sync.TimeLimit))
@@ -210,6 +483,127 @@ func (c *Config) validate() error {
 			}
 		}
 
+		if IntValue(sync.Retries) < 0 {
+			return fmt.Errorf("retries can't be negative for sync: %s", name)
+		}
+
+		if sync.RetryBackoff != nil {
+			var err error
+			sync.retryBackoff, err = time.ParseDuration(StringValue(sync.RetryBackoff))
+			if err != nil {
+				return err
+			}
+
+			if sync.retryBackoff < 0 {
+				return fmt.Errorf("retry_backoff can't be negative for sync: %s", name)
+			}
+		}
+
+		if sync.Jitter != nil {
+			var err error
+			sync.jitter, err = time.ParseDuration(StringValue(sync.Jitter))
+			if err != nil {
+				return err
+			}
+
+			if sync.jitter < 0 {
+				return fmt.Errorf("jitter can't be negative for sync: %s", name)
+			}
+		}
+
+		if sync.GracePeriod != nil {
+			var err error
+			sync.gracePeriod, err = time.ParseDuration(StringValue(sync.GracePeriod))
+			if err != nil {
+				return err
+			}
+
+			if sync.gracePeriod < 0 {
+				return fmt.Errorf("grace_period can't be negative for sync: %s", name)
+			}
+		}
+
+		if sync.HookTimeout != nil {
+			var err error
+			sync.hookTimeout, err = time.ParseDuration(StringValue(sync.HookTimeout))
+			if err != nil {
+				return err
+			}
+
+			if sync.hookTimeout <= 0 {
+				return fmt.Errorf("hook_timeout must be greater than 0 for sync: %s", name)
+			}
+		}
+
+		windowClose := sync.WindowClose
+		if windowClose == nil {
+			windowClose = c.WindowClose
+		}
+
+		if err := validateWindows(sync.Windows, sync.Blackout, windowClose); err != nil {
+			return fmt.Errorf("%s for sync: %s", err, name)
+		}
+
+		for _, b := range sync.Bandwidth {
+			if b == nil {
+				return fmt.Errorf("Bandwidth can't be empty for sync: %s", name)
+			}
+
+			if err := b.validate(); err != nil {
+				return fmt.Errorf("%s for sync: %s", err, name)
+			}
+		}
+
+		if BoolValue(sync.BandwidthRestart) && len(sync.Bandwidth) == 0 {
+			return fmt.Errorf("bandwidth_restart requires a bandwidth entry for sync: %s", name)
+		}
+
+		if BoolValue(sync.CatchUp) {
+			if sync.Schedule == nil {
+				return fmt.Errorf("catch_up requires a schedule for sync: %s", name)
+			}
+
+			if IntValue(c.Retention) < 1 {
+				return fmt.Errorf("catch_up requires a retention of at least 1 for sync: %s", name)
+			}
+		}
+
+		if sync.Overlap == nil {
+			sync.Overlap = String(OverlapSkip)
+		}
+
+		switch StringValue(sync.Overlap) {
+		case OverlapSkip, OverlapQueue, OverlapReplace:
+		default:
+			return fmt.Errorf("Invalid overlap %s for sync: %s", StringValue(sync.Overlap), name)
+		}
+
+		for _, code := range sync.WarningExitCodes {
+			if code < 1 || code > 255 {
+				return fmt.Errorf("Invalid warning_exit_codes entry %d for sync: %s", code, name)
+			}
+		}
+
+		if sync.MaxAge != nil {
+			var err error
+			sync.maxAge, err = time.ParseDuration(StringValue(sync.MaxAge))
+			if err != nil {
+				return err
+			}
+
+			if sync.maxAge <= 0 {
+				return fmt.Errorf("max_age must be greater than 0 for sync: %s", name)
+			}
+
+			if IntValue(c.Retention) < 1 {
+				return fmt.Errorf("max_age requires a retention of at least 1 for sync: %s", name)
+			}
+		}
+
+		if BoolValue(sync.Manifest) && IntValue(c.Retention) < 1 {
+			return fmt.Errorf("manifest requires a retention of at least 1 for sync: %s", name)
+		}
+
 		if sync.RsyncArgs == nil {
 			return fmt.Errorf("Missing rsync_args entry for sync: %s", name)
 		}
@@ -223,9 +617,78 @@ func (c *Config) validate() error {
 		}
 	}
 
+	return c.validateDependencies()
+}
+
+// validateDependencies returns an error if the depends_on entries of the syncs form a cycle.
+func (c *Config) validateDependencies() error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		path = append(path, name)
+
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("Dependency cycle in depends_on: %s", strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		for _, dep := range c.Syncs[name].DependsOn {
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+
+		return nil
+	}
+
+	// visit in a consistent order so the same cycle is always reported
+	names := make([]string, 0, len(c.Syncs))
+	for name := range c.Syncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Dependents returns the names of the syncs that depend on the sync with name sorted by name.
+func (c *Config) Dependents(name string) []string {
+	dependents := make([]string, 0)
+	for dependent, sync := range c.Syncs {
+		if contains(sync.DependsOn, name) {
+			dependents = append(dependents, dependent)
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// Overlap policies decide what happens when a sync is started while it's already running.
+const (
+	// OverlapSkip skips the new run.
+	OverlapSkip = "skip"
+
+	// OverlapQueue runs the sync once more as soon as the running sync finishes.
+	OverlapQueue = "queue"
+
+	// OverlapReplace cancels the running sync and then runs it again.
+	OverlapReplace = "replace"
+)
+
 // Sync defines a single rsync command, cron expression, and other related options.
 type Sync struct {
 	// RsyncArgs are all of the arguments needed to run your rsync command
@@ -237,19 +700,161 @@ type Sync struct {
 	// RsyncDestination is the location of the rsync command's destination
 	RsyncDestination *string `yaml:"rsync_destination"`
 
-	// Schedule is the cron expresion for this sync.
+	// Schedule is the cron expresion for this sync. Schedule is required unless DependsOn is set.
 	Schedule *string `yaml:"schedule"`
 
+	// DependsOn is a list of syncs that must succeed before this sync runs. The sync runs once every sync in
+	// DependsOn has succeeded since it last ran. DependsOn can't be used with Schedule.
+	DependsOn []string `yaml:"depends_on"`
+
 	// TimeLimit is the maximum amount of time that a sync job will run before being killed. TimeLimit
 	// must be a string that can be passed to the time.Duration.ParseDuration() function.
 	TimeLimit *string `yaml:"time_limit"`
 	timeLimit time.Duration
+
+	// MaxAge is the maximum amount of time since the last successful run before the sync is considered unhealthy.
+	// MaxAge must be a string that can be passed to the time.Duration.ParseDuration() function.
+	MaxAge *string `yaml:"max_age"`
+	maxAge time.Duration
+
+	// Retries is the number of times a failed sync is run again before giving up. Overrides the global retries.
+	Retries *int `yaml:"retries"`
+
+	// RetryBackoff is how long to wait before the first retry. Overrides the global retry_backoff.
+	RetryBackoff *string `yaml:"retry_backoff"`
+	retryBackoff time.Duration
+
+	// WarningExitCodes are rsync exit codes that count as a successful sync with a warning instead of a failure.
+	// For example 24 when files vanish from a live source during the sync.
+	WarningExitCodes []int `yaml:"warning_exit_codes"`
+
+	// Manifest stores every path changed by each run of the sync. If true then --itemize-changes is added to the
+	// rsync args.
+	Manifest *bool `yaml:"manifest"`
+
+	// Priority orders the syncs that are waiting to run because of max_concurrent. Syncs with a higher priority run
+	// first and syncs with the same priority run in the order they were queued. Defaults to 0.
+	Priority *int `yaml:"priority"`
+
+	// RunOnStart runs the sync once as soon as resync starts.
+	RunOnStart *bool `yaml:"run_on_start"`
+
+	// Jitter is the maximum random delay added each time the sync's cron job fires. Overrides the global jitter.
+	Jitter *string `yaml:"jitter"`
+	jitter time.Duration
+
+	// GracePeriod is how long rsync has to exit after it's sent SIGTERM. Overrides the global grace_period.
+	GracePeriod *string `yaml:"grace_period"`
+	gracePeriod time.Duration
+
+	// HookTimeout is the maximum amount of time each of the sync's hooks can run. Overrides the global hook_timeout.
+	HookTimeout *string `yaml:"hook_timeout"`
+	hookTimeout time.Duration
+
+	// Windows are the times when the sync is allowed to start. Overrides the global windows.
+	Windows []*Window `yaml:"windows"`
+
+	// Blackout are the times when the sync isn't allowed to start. Overrides the global blackout.
+	Blackout []*Window `yaml:"blackout"`
+
+	// WindowClose is what happens to the running sync when its window closes. Overrides the global window_close.
+	WindowClose *string `yaml:"window_close"`
+
+	// Bandwidth is a list of bandwidth limits for different times of day. The limit of the first entry that contains
+	// the time rsync starts is passed to rsync as --bwlimit. If none of them contain it then rsync isn't limited
+	// beyond RsyncArgs.
+	Bandwidth []*Bandwidth `yaml:"bandwidth"`
+
+	// BandwidthRestart restarts rsync with the new limit when the bandwidth limit changes while the sync is running.
+	// Restarts aren't counted as retries. Requires Bandwidth.
+	BandwidthRestart *bool `yaml:"bandwidth_restart"`
+
+	// CatchUp runs the sync once when resync starts if its cron job should have fired while resync was stopped.
+	// Requires a Schedule and a retention of at least 1.
+	CatchUp *bool `yaml:"catch_up"`
+
+	// Overlap decides what happens when the sync is started while it's already running. Valid policies are skip,
+	// queue, and replace. Skipped runs are stored with the sync's stats. Defaults to skip.
+	Overlap *string `yaml:"overlap"`
+
+	// PreCommand is run with the shell before rsync. If it fails then rsync isn't run and the run fails.
+	PreCommand *string `yaml:"pre_command"`
+
+	// PostCommand is run with the shell after every run even if the run or PreCommand failed.
+	PostCommand *string `yaml:"post_command"`
+
+	// OnSuccessCommand is run with the shell after a successful run and before PostCommand.
+	OnSuccessCommand *string `yaml:"on_success_command"`
+
+	// OnFailureCommand is run with the shell after a run that failed, timed out, or was cancelled and before
+	// PostCommand.
+	OnFailureCommand *string `yaml:"on_failure_command"`
+}
+
+// IsWarning returns true if code is one of the sync's warning exit codes.
+func (s *Sync) IsWarning(code int) bool {
+	for _, c := range s.WarningExitCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// DestinationHost returns the host of the rsync destination. It returns an empty string for local destinations.
+func (s *Sync) DestinationHost() string {
+	dest := StringValue(s.RsyncDestination)
+
+	var host string
+	switch {
+	case strings.HasPrefix(dest, "rsync://"):
+		host = strings.TrimPrefix(dest, "rsync://")
+		if i := strings.IndexByte(host, '/'); i >= 0 {
+			host = host[:i]
+		}
+	case strings.Contains(dest, ":"):
+		// remote destinations look like host:path or host::module but a colon after a slash is part of a local path and
+		// a single letter before the colon is a Windows drive
+		i := strings.IndexByte(dest, ':')
+		if j := strings.IndexByte(dest, '/'); (j >= 0 && j < i) || i == 1 {
+			return ""
+		}
+		host = dest[:i]
+	default:
+		return ""
+	}
+
+	// ignore the user and port
+	if i := strings.LastIndexByte(host, '@'); i >= 0 {
+		host = host[i+1:]
+	}
+	if i := strings.LastIndexByte(host, ':'); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+// BandwidthAt returns the bandwidth limit of the sync at t. It returns false if the sync doesn't have a limit at t.
+func (s *Sync) BandwidthAt(t time.Time) (string, bool) {
+	return bandwidthAt(s.Bandwidth, t)
 }
 
-// Args returns a list of args suitable for exec.Command.
+// Args returns a list of args suitable for exec.Command using the bandwidth limit for the current time.
 func (s *Sync) Args() []string {
+	return s.ArgsAt(time.Now())
+}
+
+// ArgsAt returns a list of args suitable for exec.Command using the bandwidth limit at t. The limit comes after
+// RsyncArgs so that it overrides any --bwlimit in them.
+func (s *Sync) ArgsAt(t time.Time) []string {
 	args := make([]string, 0)
 	args = append(args, strings.Fields(StringValue(s.RsyncArgs))...)
+	if BoolValue(s.Manifest) && !hasItemizeArg(args) {
+		args = append(args, "--itemize-changes")
+	}
+	if limit, ok := s.BandwidthAt(t); ok {
+		args = append(args, "--bwlimit="+limit)
+	}
 	args = append(args, s.RsyncSource...)
 	args = append(args, StringValue(s.RsyncDestination))
 	return args
@@ -262,6 +867,29 @@ type HTTP struct {
 
 	// The port the http server will listen on.
 	Port *int `yaml:"port"`
+
+	// CertFile is the path to a PEM encoded certificate. If both CertFile and KeyFile are set then the http server
+	// uses TLS.
+	CertFile *string `yaml:"cert_file"`
+
+	// KeyFile is the path to the PEM encoded private key for CertFile.
+	KeyFile *string `yaml:"key_file"`
+
+	// Tokens is a list of bearer tokens that are allowed to access the http server.
+	Tokens []string `yaml:"tokens"`
+
+	// Users is a map of usernames to passwords that are allowed to access the http server using basic auth.
+	Users map[string]string `yaml:"users"`
+}
+
+// TLS returns true if the http server uses TLS.
+func (h *HTTP) TLS() bool {
+	return h.CertFile != nil && h.KeyFile != nil
+}
+
+// Auth returns true if the http server requires authentication.
+func (h *HTTP) Auth() bool {
+	return len(h.Tokens) > 0 || len(h.Users) > 0
 }
 
 // Email defines the SMTP configuration options needed when sending email notifications.
@@ -304,6 +932,9 @@ type Email struct {
 
 	// OnFailure will send an email for each sync failure if true.
 	OnFailure *bool `yaml:"on_failure"`
+
+	// OnSkip will send an email each time a sync is skipped because it's already running if true.
+	OnSkip *bool `yaml:"on_skip"`
 }
 
 // OpenConfig returns a new Config option by reading the YAML file at path. If the file
@@ -226,3 +226,583 @@ func TestMissingConfig(t *testing.T) {
 	_, err := OpenConfig("./testdata/missing.yaml")
 	assert.Error(t, err)
 }
+
+func TestHTTP(t *testing.T) {
+	config := &Config{
+		HTTP: &HTTP{},
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+			},
+		},
+	}
+
+	err := config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, StringValue(config.HTTP.Addr), "127.0.0.1")
+	assert.Equal(t, IntValue(config.HTTP.Port), 4050)
+	assert.False(t, config.HTTP.TLS())
+	assert.False(t, config.HTTP.Auth())
+
+	config.HTTP.CertFile = String("/etc/resync/cert.pem")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.HTTP.KeyFile = String("/etc/resync/key.pem")
+	err = config.validate()
+	assert.Nil(t, err)
+	assert.True(t, config.HTTP.TLS())
+
+	config.HTTP.Tokens = []string{""}
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.HTTP.Tokens = []string{"token"}
+	err = config.validate()
+	assert.Nil(t, err)
+	assert.True(t, config.HTTP.Auth())
+
+	config.HTTP.Users = map[string]string{"me": ""}
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.HTTP.Users = map[string]string{"me": "pass"}
+	err = config.validate()
+	assert.Nil(t, err)
+}
+
+func TestMaxAge(t *testing.T) {
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+				MaxAge:           String("invalid"),
+			},
+		},
+	}
+	err := config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["test"].MaxAge = String("24h")
+	err = config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, 24*time.Hour, config.Syncs["test"].maxAge)
+
+	config.Syncs["test"].MaxAge = String("-1h")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["test"].MaxAge = String("0s")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["test"].MaxAge = String("24h")
+	config.Retention = Int(0)
+	err = config.validate()
+	assert.Error(t, err)
+}
+
+func TestRetries(t *testing.T) {
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+			},
+		},
+	}
+	err := config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, config.GetRetries("test"))
+	assert.Equal(t, 30*time.Second, config.GetRetryBackoff("test"))
+
+	config.Retries = Int(2)
+	config.RetryBackoff = String("1m")
+	err = config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, config.GetRetries("test"))
+	assert.Equal(t, time.Minute, config.GetRetryBackoff("test"))
+
+	config.Syncs["test"].Retries = Int(5)
+	config.Syncs["test"].RetryBackoff = String("10s")
+	err = config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, 5, config.GetRetries("test"))
+	assert.Equal(t, 10*time.Second, config.GetRetryBackoff("test"))
+
+	config.Syncs["test"].RetryBackoff = String("invalid")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["test"].RetryBackoff = nil
+	config.Syncs["test"].Retries = Int(-1)
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["test"].Retries = nil
+	config.Syncs["test"].RetryBackoff = String("-10s")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["test"].RetryBackoff = nil
+	config.RetryBackoff = String("-1m")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.RetryBackoff = String("1m")
+	config.Retries = Int(-1)
+	err = config.validate()
+	assert.Error(t, err)
+}
+
+func TestWarningExitCodes(t *testing.T) {
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+				WarningExitCodes: []int{0},
+			},
+		},
+	}
+	err := config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["test"].WarningExitCodes = []int{256}
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["test"].WarningExitCodes = []int{23, 24}
+	err = config.validate()
+	assert.Nil(t, err)
+	assert.True(t, config.Syncs["test"].IsWarning(24))
+	assert.False(t, config.Syncs["test"].IsWarning(12))
+}
+
+func TestManifestConfig(t *testing.T) {
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"_resync_manifests": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+			},
+		},
+	}
+	err := config.validate()
+	assert.Error(t, err)
+
+	sync := &Sync{
+		RsyncArgs:        String("-a"),
+		RsyncSource:      []string{"/a/b/c"},
+		RsyncDestination: String("/d/e/f"),
+	}
+	assert.Equal(t, []string{"-a", "/a/b/c", "/d/e/f"}, sync.Args())
+
+	sync.Manifest = Bool(true)
+	assert.Equal(t, []string{"-a", "--itemize-changes", "/a/b/c", "/d/e/f"}, sync.Args())
+
+	sync.RsyncArgs = String("-a -i")
+	assert.Equal(t, []string{"-a", "-i", "/a/b/c", "/d/e/f"}, sync.Args())
+
+	// i in a cluster of short options also itemizes changes
+	sync.RsyncArgs = String("-avi")
+	assert.Equal(t, []string{"-avi", "/a/b/c", "/d/e/f"}, sync.Args())
+
+	sync.RsyncArgs = String("-a --itemize-changes")
+	assert.Equal(t, []string{"-a", "--itemize-changes", "/a/b/c", "/d/e/f"}, sync.Args())
+
+	// the values of short options aren't options
+	sync.RsyncArgs = String("-aessh_id")
+	assert.Equal(t, []string{"-aessh_id", "--itemize-changes", "/a/b/c", "/d/e/f"}, sync.Args())
+
+	sync.RsyncArgs = String("--info=progress2 -a")
+	assert.Equal(t, []string{"--info=progress2", "-a", "--itemize-changes", "/a/b/c", "/d/e/f"}, sync.Args())
+
+	// manifests are stored in the database
+	config = &Config{
+		Retention: Int(0),
+		Syncs: map[string]*Sync{
+			"test": sync,
+		},
+	}
+	sync.Schedule = String("* * * * * *")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Retention = Int(1)
+	err = config.validate()
+	assert.Nil(t, err)
+}
+
+func TestDependsOn(t *testing.T) {
+	newSync := func(deps ...string) *Sync {
+		sync := &Sync{
+			RsyncArgs:        String("-a"),
+			RsyncSource:      []string{"/a/b/c"},
+			RsyncDestination: String("/d/e/f"),
+			DependsOn:        deps,
+		}
+		if len(deps) == 0 {
+			sync.Schedule = String("* * * * *")
+		}
+		return sync
+	}
+
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"local":   newSync(),
+			"staging": newSync(),
+			"offsite": newSync("local", "staging"),
+			"archive": newSync("offsite"),
+		},
+	}
+	err := config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"offsite"}, config.Dependents("local"))
+	assert.Equal(t, []string{"archive"}, config.Dependents("offsite"))
+	assert.Len(t, config.Dependents("archive"), 0)
+
+	config.Syncs["archive"].DependsOn = []string{"missing"}
+	err = config.validate()
+	assert.EqualError(t, err, "Unknown sync missing in depends_on for sync: archive")
+
+	config.Syncs["archive"].DependsOn = []string{"offsite"}
+	config.Syncs["offsite"].Schedule = String("* * * * *")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["offsite"].Schedule = nil
+	config.Syncs["local"] = newSync("archive")
+	err = config.validate()
+	assert.EqualError(t, err, "Dependency cycle in depends_on: archive -> offsite -> local -> archive")
+
+	config.Syncs["local"] = newSync("local")
+	err = config.validate()
+	assert.EqualError(t, err, "Dependency cycle in depends_on: archive -> offsite -> local -> local")
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+			},
+		},
+	}
+	err := config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, IntValue(config.MaxConcurrent))
+	assert.Equal(t, 0, IntValue(config.MaxConcurrentPerDestination))
+
+	config.MaxConcurrent = Int(-1)
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.MaxConcurrent = Int(2)
+	config.MaxConcurrentPerDestination = Int(-1)
+	err = config.validate()
+	assert.Error(t, err)
+}
+
+func TestDestinationHost(t *testing.T) {
+	tests := map[string]string{
+		"/mnt/backup/":                  "",
+		"backup/a:b":                    "",
+		"C:/backup":                     "",
+		"nas:/backup/":                  "nas",
+		"user@nas.local:/backup/":       "nas.local",
+		"nas::module/path":              "nas",
+		"rsync://user@nas:873/module/a": "nas",
+	}
+
+	for dest, host := range tests {
+		sync := &Sync{
+			RsyncDestination: String(dest),
+		}
+		assert.Equal(t, host, sync.DestinationHost(), dest)
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+			},
+		},
+	}
+	err := config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, OverlapSkip, StringValue(config.Syncs["test"].Overlap))
+
+	for _, overlap := range []string{OverlapSkip, OverlapQueue, OverlapReplace} {
+		config.Syncs["test"].Overlap = String(overlap)
+		err = config.validate()
+		assert.Nil(t, err)
+	}
+
+	config.Syncs["test"].Overlap = String("wait")
+	err = config.validate()
+	assert.Error(t, err)
+}
+
+func TestCatchUp(t *testing.T) {
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+				CatchUp:          Bool(true),
+			},
+			"after": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				DependsOn:        []string{"test"},
+			},
+		},
+	}
+	err := config.validate()
+	assert.Nil(t, err)
+
+	config.Syncs["after"].CatchUp = Bool(true)
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["after"].CatchUp = nil
+	config.Retention = Int(0)
+	err = config.validate()
+	assert.Error(t, err)
+}
+
+func TestJitter(t *testing.T) {
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+			},
+			"override": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+				Jitter:           String("5m"),
+				RunOnStart:       Bool(true),
+			},
+		},
+	}
+	err := config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), config.GetJitter("test"))
+	assert.Equal(t, 5*time.Minute, config.GetJitter("override"))
+
+	config.Jitter = String("1m")
+	err = config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, config.GetJitter("test"))
+	assert.Equal(t, 5*time.Minute, config.GetJitter("override"))
+
+	config.Jitter = String("-1m")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Jitter = nil
+	config.Syncs["override"].Jitter = String("-1m")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["override"].Jitter = String("1x")
+	err = config.validate()
+	assert.Error(t, err)
+}
+
+func TestWindows(t *testing.T) {
+	config := &Config{
+		Blackout: []*Window{
+			{Days: []string{"mon-fri"}, Start: String("09:00"), End: String("17:00")},
+		},
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+			},
+			"nightly": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+				Windows: []*Window{
+					{Start: String("22:00"), End: String("06:00")},
+				},
+				WindowClose: String(WindowCloseCancel),
+			},
+			"always": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+				Blackout:         []*Window{},
+			},
+		},
+	}
+	err := config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, WindowCloseFinish, config.GetWindowClose("test"))
+	assert.Equal(t, WindowCloseCancel, config.GetWindowClose("nightly"))
+
+	// Mon Jan 01 2024
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	night := time.Date(2024, 1, 1, 23, 0, 0, 0, time.Local)
+	assert.False(t, config.Allowed("test", noon))
+	assert.True(t, config.Allowed("test", night))
+	assert.True(t, config.Allowed("test", noon.AddDate(0, 0, -1)))
+	assert.False(t, config.Allowed("nightly", noon))
+	assert.True(t, config.Allowed("nightly", night))
+	assert.True(t, config.Allowed("always", noon))
+
+	config.WindowClose = String("stop")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.WindowClose = nil
+	config.Syncs["nightly"].WindowClose = String("stop")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.Syncs["nightly"].WindowClose = nil
+	config.Syncs["nightly"].Windows = []*Window{{Start: String("22:00")}}
+	err = config.validate()
+	assert.Error(t, err)
+}
+
+func TestBandwidthConfig(t *testing.T) {
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a --bwlimit=10M"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+				Bandwidth: []*Bandwidth{
+					{Window: Window{Days: []string{"mon-fri"}, Start: String("08:00"), End: String("18:00")}, Limit: String("2M")},
+				},
+				BandwidthRestart: Bool(true),
+			},
+		},
+	}
+	err := config.validate()
+	assert.Nil(t, err)
+
+	// Mon Jan 01 2024
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	sync := config.Syncs["test"]
+	assert.Equal(t, []string{"-a", "--bwlimit=10M", "--bwlimit=2M", "/a/b/c", "/d/e/f"}, sync.ArgsAt(noon))
+	assert.Equal(t, []string{"-a", "--bwlimit=10M", "/a/b/c", "/d/e/f"}, sync.ArgsAt(noon.AddDate(0, 0, -1)))
+
+	sync.Bandwidth[0].Limit = String("fast")
+	err = config.validate()
+	assert.Error(t, err)
+
+	sync.Bandwidth = []*Bandwidth{nil}
+	err = config.validate()
+	assert.Error(t, err)
+
+	sync.Bandwidth = nil
+	err = config.validate()
+	assert.Error(t, err)
+
+	sync.BandwidthRestart = nil
+	err = config.validate()
+	assert.Nil(t, err)
+}
+
+func TestHookTimeout(t *testing.T) {
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+			},
+			"override": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+				HookTimeout:      String("1m"),
+			},
+		},
+	}
+	err := config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Minute, config.GetHookTimeout("test"))
+	assert.Equal(t, time.Minute, config.GetHookTimeout("override"))
+
+	config.HookTimeout = String("0s")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.HookTimeout = String("5m")
+	config.Syncs["override"].HookTimeout = String("-1s")
+	err = config.validate()
+	assert.Error(t, err)
+}
+
+func TestGracePeriod(t *testing.T) {
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+			},
+			"override": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("* * * * * *"),
+				GracePeriod:      String("1m"),
+			},
+		},
+	}
+	err := config.validate()
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Second, config.GetGracePeriod("test"))
+	assert.Equal(t, time.Minute, config.GetGracePeriod("override"))
+
+	config.GracePeriod = String("-1s")
+	err = config.validate()
+	assert.Error(t, err)
+
+	config.GracePeriod = String("0s")
+	config.Syncs["override"].GracePeriod = String("-1s")
+	err = config.validate()
+	assert.Error(t, err)
+}
@@ -0,0 +1,217 @@
+package resync
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+)
+
+// dashboardSync is the data used to render a single sync in the dashboard.
+type dashboardSync struct {
+	SyncStatus
+	History []Stat
+}
+
+// dashboardData is the data used to render the dashboard. TimeFormat is the configured time_format.
+type dashboardData struct {
+	TimeFormat string
+	Syncs      []dashboardSync
+}
+
+// handleDashboard renders an HTML page with the status and history of every sync.
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	statMap, err := s.db.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	statuses, err := s.resync.statuses(statMap)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	syncs := make([]dashboardSync, 0, len(statuses))
+	for _, status := range statuses {
+		syncs = append(syncs, dashboardSync{
+			SyncStatus: status,
+			History:    statMap[status.Name],
+		})
+	}
+
+	var buf bytes.Buffer
+	data := dashboardData{
+		TimeFormat: StringValue(s.config.TimeFormat),
+		Syncs:      syncs,
+	}
+	if err := dashboardTmpl.Execute(&buf, data); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
+
+var dashboardTmpl = template.Must(template.New("dashboard").Parse(dashboardTemplate))
+
+var dashboardTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Resync</title>
+
+<style type="text/css">
+body {
+  font-family:Roboto,"Helvetica Neue",sans-serif;
+  margin: 20px;
+}
+
+.tg {
+  border-collapse:separate;
+  border-spacing:0;
+  width: 100%;
+}
+
+.tg td {
+  color:#444;
+  font-size:14px;
+  padding:3px 10px 3px 0px;
+  border-bottom: 1px solid;
+  border-bottom-color: #BDBDBD;
+  height: 40px;
+}
+
+.tg th {
+  background-color:#424242;
+  color:#FFFFFF;
+  font-size:14px;
+  font-weight:bold;
+  padding:3px 10px;
+  height: 60px;
+  text-align:left;
+}
+
+.tg .tg-header {
+  font-weight: bold;
+  color: #9E9E9E;
+}
+
+.tg .tg-actions {
+  float: right;
+}
+
+.success {
+  color: #2E7D32 !important;
+  font-weight: bold;
+}
+
+.failure {
+  color: #D32F2F !important;
+  font-weight: bold;
+}
+
+.warning {
+  color: #EF6C00 !important;
+  font-weight: bold;
+}
+
+.running {
+  color: #1565C0 !important;
+  font-weight: bold;
+}
+</style>
+
+<script>
+function post(url) {
+  fetch(url, {method: "POST"})
+    .then(function(resp) { return resp.json(); })
+    .then(function(body) {
+      if (body.Error) {
+        alert(body.Error);
+      }
+      location.reload();
+    });
+}
+</script>
+
+</head>
+<body>
+
+{{ range .Syncs }}
+<table class="tg">
+        <thead>
+                <tr>
+                        <th colspan="3">{{.Name}}</th>
+                        <th colspan="2">
+                                <span class="tg-actions">
+                                        <button onclick="post('/syncs/{{.Name}}/run')">Run</button>
+                                        <button onclick="post('/syncs/{{.Name}}/cancel')" {{if not (or .Running .Queued)}}disabled{{end}}>Cancel</button>
+                                </span>
+                        </th>
+                </tr>
+        </thead>
+        <tbody>
+                <tr>
+                        <td class="tg-header">Schedule</td>
+                        <td class="tg-header">State</td>
+                        <td class="tg-header">Next Run</td>
+                        <td class="tg-header">Time Limit</td>
+                        <td class="tg-header">Logs</td>
+                </tr>
+                <tr>
+                        <td>{{if .Schedule}}{{.Schedule}}{{else}}After {{range $i, $dep := .DependsOn}}{{if $i}}, {{end}}{{$dep}}{{end}}{{end}}</td>
+                        {{if .Running}}
+                          <td class="running">Running{{with .Progress}} {{.Percent}}% ETA {{.ETA}}{{end}}</td>
+                        {{else if .Queued}}
+                          <td>Queued</td>
+                        {{else}}
+                          <td>Idle</td>
+                        {{end}}
+                        <td>{{if not .Next.IsZero}}{{.Next.Format $.TimeFormat}}{{end}}</td>
+                        <td>{{if .TimeLimit}}{{.TimeLimit}}{{else}}None{{end}}</td>
+                        <td><a href="/syncs/{{.Name}}/stdout">stdout</a> <a href="/syncs/{{.Name}}/stderr">stderr</a></td>
+                </tr>
+                <tr>
+                        <td class="tg-header">Status</td>
+                        <td class="tg-header">Start</td>
+                        <td class="tg-header">End</td>
+                        <td class="tg-header" colspan="2">Duration</td>
+                </tr>
+                {{ range .History }}
+                <tr>
+                        {{if eq .Status "warning"}}
+                          <td class="warning" title="{{.ExitDescription}}">Warning</td>
+                        {{else if eq .Status "success"}}
+                          <td class="success">Success</td>
+                        {{else if eq .Status "skipped"}}
+                          <td title="{{.ExitDescription}}">Skipped</td>
+                        {{else if eq .Status "cancelled"}}
+                          <td title="{{.EndReason}}">Cancelled</td>
+                        {{else if eq .Status "timed_out"}}
+                          <td class="failure">Timed Out</td>
+                        {{else}}
+                          <td class="failure" title="{{.ExitDescription}}">Failed</td>
+                        {{end}}
+                        <td>{{.Start}}</td>
+                        <td>{{.End}}</td>
+                        <td colspan="2">{{.Duration}}</td>
+                </tr>
+                {{ end }}
+        </tbody>
+</table>
+<br>
+{{ end }}
+
+</body>
+</html>`
@@ -0,0 +1,45 @@
+package resync
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboard(t *testing.T) {
+	re, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	_, err := re.Run("test")
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "<th colspan=\"3\">test</th>")
+	assert.Contains(t, w.Body.String(), "Running")
+	assert.Contains(t, w.Body.String(), "/syncs/test/stdout")
+
+	status, err := re.Status("test")
+	assert.Nil(t, err)
+	assert.Contains(t, w.Body.String(), status.Next.Format(StringValue(server.config.TimeFormat)))
+
+	time.Sleep(1500 * time.Millisecond)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Success")
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/foo", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
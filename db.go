@@ -1,5 +1,7 @@
 package resync
 
+import "time"
+
 // DB defines an interface for persisting Stats. Stats are simple metrics
 // for each job.
 type DB interface {
@@ -13,6 +15,19 @@ type DB interface {
 	// Insert adds a stat to the database.
 	Insert(Stat) error
 
+	// InsertManifest adds the manifest for a single run to the database. Manifests are pruned with the stats.
+	InsertManifest(Manifest) error
+
+	// ListManifests returns the stored manifests for the sync with name sorted by Start in descending order.
+	ListManifests(name string) ([]Manifest, error)
+
+	// SetLastScheduled stores when the cron job for the sync with name last fired.
+	SetLastScheduled(name string, t time.Time) error
+
+	// LastScheduled returns when the cron job for the sync with name last fired. It returns the zero time if it
+	// hasn't been stored.
+	LastScheduled(name string) (time.Time, error)
+
 	// Closes the connection the database
 	Close() error
 }
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,6 +13,16 @@ import (
 	bolt "go.etcd.io/bbolt"
 )
 
+// reservedPrefix starts the names of buckets that resync uses for its own data instead of for the stats of a sync.
+// Sync names can't start with it.
+const reservedPrefix = "_resync_"
+
+// manifestBucket holds a nested bucket of manifests for each sync.
+const manifestBucket = reservedPrefix + "manifests"
+
+// scheduleBucket holds the last time the cron job for each sync fired keyed by sync name.
+const scheduleBucket = reservedPrefix + "schedule"
+
 // BoltDB is the default and only database for storing stats. In the future
 // other databases could be added.
 type BoltDB struct {
@@ -73,7 +83,7 @@ func (s *BoltDB) Insert(stat Stat) error {
 		}
 
 		// store stat by sortable start time
-		if err := b.Put([]byte(stat.start.Format(time.RFC3339Nano)), encoded); err != nil {
+		if err := b.Put([]byte(stat.StartTime.Format(time.RFC3339Nano)), encoded); err != nil {
 			return fmt.Errorf("BoltDB: put: %s", err)
 		}
 
@@ -102,20 +112,20 @@ func (s *BoltDB) prune() error {
 
 	err := s.db.Update(func(tx *bolt.Tx) error {
 		tx.ForEach(func(name []byte, b *bolt.Bucket) error {
-			stats := b.Stats()
-			count := stats.KeyN
-			cursor := b.Cursor()
-			cursor.First()
-
-			for count > IntValue(s.config.Retention) {
-				if err := cursor.Delete(); err != nil {
-					return fmt.Errorf("BoltDB: failed delete: %s", err)
-				}
-				cursor.Next()
-				count--
+			if strings.HasPrefix(string(name), reservedPrefix) {
+				return nil
 			}
-			return nil
+			return s.pruneBucket(b, true)
 		})
+
+		if manifests := tx.Bucket([]byte(manifestBucket)); manifests != nil {
+			manifests.ForEach(func(name, _ []byte) error {
+				if b := manifests.Bucket(name); b != nil {
+					return s.pruneBucket(b, false)
+				}
+				return nil
+			})
+		}
 		return nil
 	})
 	if err != nil {
@@ -124,6 +134,47 @@ func (s *BoltDB) prune() error {
 	return nil
 }
 
+// pruneBucket removes the oldest entries from b that exceed the retention config. If countSkipped is true the entries
+// are decoded as stats and skipped runs are counted separately from other runs so that a sync that's skipped often
+// doesn't push its real runs out.
+func (s *BoltDB) pruneBucket(b *bolt.Bucket, countSkipped bool) error {
+	retention := IntValue(s.config.Retention)
+	runs, skipped := 0, 0
+	old := make([][]byte, 0)
+
+	cursor := b.Cursor()
+	for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+		count := &runs
+		if countSkipped && isSkipped(v) {
+			count = &skipped
+		}
+
+		*count++
+		if *count > retention {
+			old = append(old, append([]byte{}, k...))
+		}
+	}
+
+	for _, k := range old {
+		if err := b.Delete(k); err != nil {
+			return fmt.Errorf("BoltDB: failed delete: %s", err)
+		}
+	}
+	return nil
+}
+
+// isSkipped returns true if the encoded stat is a skipped run. Stats that can't be decoded aren't.
+func isSkipped(encoded []byte) bool {
+	stat := struct {
+		Status  Status
+		Skipped bool
+	}{}
+	if err := json.Unmarshal(encoded, &stat); err != nil {
+		return false
+	}
+	return stat.Status == StatusSkipped || stat.Skipped
+}
+
 // List returns all stats stored as a map. The map keys are sync names and the values are a list of all stored stats for.
 // that sysnc. Stats are returned storted by Start in descending order.
 func (s *BoltDB) List() (map[string][]Stat, error) {
@@ -135,6 +186,10 @@ func (s *BoltDB) List() (map[string][]Stat, error) {
 
 	err := s.db.View(func(tx *bolt.Tx) error {
 		tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			if strings.HasPrefix(string(name), reservedPrefix) {
+				return nil
+			}
+
 			b.ForEach(func(k, v []byte) error {
 				stat := Stat{}
 				if err := json.Unmarshal(v, &stat); err != nil {
@@ -156,16 +211,161 @@ func (s *BoltDB) List() (map[string][]Stat, error) {
 		return nil, fmt.Errorf("BoltDB: failed transaction: %s", err)
 	}
 
-	// return sorted by start desc
+	// bolt keys are sorted by start asc so reverse them to return sorted by start desc
 	for _, stats := range statMap {
-		sort.Slice(stats, func(i, j int) bool {
-			return j < i
-		})
+		for i, j := 0, len(stats)-1; i < j; i, j = i+1, j-1 {
+			stats[i], stats[j] = stats[j], stats[i]
+		}
 	}
 
 	return statMap, nil
 }
 
+// InsertManifest adds one Manifest to bolt.
+func (s *BoltDB) InsertManifest(manifest Manifest) error {
+	if IntValue(s.config.Retention) < 1 {
+		return nil
+	}
+
+	// only one goroutine can do a read/write bold transaction at a time
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		manifests, err := tx.CreateBucketIfNotExists([]byte(manifestBucket))
+		if err != nil {
+			return fmt.Errorf("BoltDB: create bucket: %s", err)
+		}
+
+		b, err := manifests.CreateBucketIfNotExists([]byte(manifest.Name))
+		if err != nil {
+			return fmt.Errorf("BoltDB: create bucket: %s", err)
+		}
+
+		// store manifest in bolt JSON encoded
+		encoded, err := json.Marshal(manifest)
+		if err != nil {
+			return fmt.Errorf("BoltDB: marshal json: %s", err)
+		}
+
+		// store manifest by the same sortable start time as the stat
+		if err := b.Put([]byte(manifest.StartTime.Format(time.RFC3339Nano)), encoded); err != nil {
+			return fmt.Errorf("BoltDB: put: %s", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("BoltDB: failed transaction: %s", err)
+	}
+
+	return s.prune()
+}
+
+// ListManifests returns the stored manifests for the sync with name sorted by Start in descending order.
+func (s *BoltDB) ListManifests(name string) ([]Manifest, error) {
+	manifests := make([]Manifest, 0)
+
+	if IntValue(s.config.Retention) < 1 {
+		return manifests, nil
+	}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		parent := tx.Bucket([]byte(manifestBucket))
+		if parent == nil {
+			return nil
+		}
+
+		b := parent.Bucket([]byte(name))
+		if b == nil {
+			return nil
+		}
+
+		// iterate in reverse to return sorted by start desc
+		cursor := b.Cursor()
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			manifest := Manifest{}
+			if err := json.Unmarshal(v, &manifest); err != nil {
+				// manifest can't be read so just skip it and log the error.
+				// It will eventually be pruned.
+				log.Error(err)
+				continue
+			}
+
+			manifests = append(manifests, manifest)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("BoltDB: failed transaction: %s", err)
+	}
+
+	return manifests, nil
+}
+
+// SetLastScheduled stores when the cron job for the sync with name last fired.
+func (s *BoltDB) SetLastScheduled(name string, t time.Time) error {
+	if IntValue(s.config.Retention) < 1 {
+		return nil
+	}
+
+	// only one goroutine can do a read/write bold transaction at a time
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(scheduleBucket))
+		if err != nil {
+			return fmt.Errorf("BoltDB: create bucket: %s", err)
+		}
+
+		if err := b.Put([]byte(name), []byte(t.Format(time.RFC3339Nano))); err != nil {
+			return fmt.Errorf("BoltDB: put: %s", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("BoltDB: failed transaction: %s", err)
+	}
+
+	return nil
+}
+
+// LastScheduled returns when the cron job for the sync with name last fired. It returns the zero time if it hasn't
+// been stored.
+func (s *BoltDB) LastScheduled(name string) (time.Time, error) {
+	var last time.Time
+
+	if IntValue(s.config.Retention) < 1 {
+		return last, nil
+	}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(scheduleBucket))
+		if b == nil {
+			return nil
+		}
+
+		v := b.Get([]byte(name))
+		if v == nil {
+			return nil
+		}
+
+		var err error
+		last, err = time.Parse(time.RFC3339Nano, string(v))
+		if err != nil {
+			return fmt.Errorf("BoltDB: parse time: %s", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return last, fmt.Errorf("BoltDB: failed transaction: %s", err)
+	}
+
+	return last, nil
+}
+
 // Close closes the bolt database file.
 func (s *BoltDB) Close() error {
 	if IntValue(s.config.Retention) < 1 {
@@ -3,6 +3,7 @@ package resync
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -124,3 +125,155 @@ func TestDBWithoutRetention(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Len(t, stats, 0)
 }
+
+func TestDBOrder(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		LibPath:   String(dir),
+		Retention: Int(20),
+	}
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	for i := 0; i < 20; i++ {
+		err = db.Insert(NewStat("TEST", "2006-01-02T15:04:05.000000000").Finish(nil))
+		assert.Nil(t, err)
+	}
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["TEST"], 20)
+
+	for i := 1; i < len(stats["TEST"]); i++ {
+		assert.True(t, stats["TEST"][i-1].Start > stats["TEST"][i].Start)
+	}
+}
+
+func TestDBManifests(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		LibPath:   String(dir),
+		Retention: Int(2),
+	}
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	manifests, err := db.ListManifests("TEST")
+	assert.Nil(t, err)
+	assert.Len(t, manifests, 0)
+
+	for i := 0; i < 3; i++ {
+		stat := NewStat("TEST", "2006-01-02T15:04:05.000000000").Finish(nil)
+		err = db.Insert(stat)
+		assert.Nil(t, err)
+
+		manifest := NewManifest(stat)
+		manifest.Entries = append(manifest.Entries, ManifestEntry{Change: ChangeCreated, Path: "file", Item: ">f+++++++++"})
+		err = db.InsertManifest(manifest)
+		assert.Nil(t, err)
+	}
+
+	// the manifests bucket isn't listed as a sync
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats, 1)
+	assert.Len(t, stats["TEST"], 2)
+
+	manifests, err = db.ListManifests("TEST")
+	assert.Nil(t, err)
+	assert.Len(t, manifests, 2)
+	assert.True(t, manifests[0].Start > manifests[1].Start)
+	assert.Equal(t, stats["TEST"][0].Start, manifests[0].Start)
+	assert.Len(t, manifests[0].Entries, 1)
+
+	err = db.Prune()
+	assert.Nil(t, err)
+}
+
+func TestDBLastScheduled(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		LibPath:   String(dir),
+		Retention: Int(1),
+	}
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	last, err := db.LastScheduled("TEST")
+	assert.Nil(t, err)
+	assert.True(t, last.IsZero())
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		err = db.SetLastScheduled("TEST", now.Add(time.Duration(i)*time.Hour))
+		assert.Nil(t, err)
+	}
+
+	// only the latest time is kept and it isn't pruned or listed as a sync
+	last, err = db.LastScheduled("TEST")
+	assert.Nil(t, err)
+	assert.True(t, now.Add(2*time.Hour).Equal(last))
+
+	err = db.Prune()
+	assert.Nil(t, err)
+
+	last, err = db.LastScheduled("TEST")
+	assert.Nil(t, err)
+	assert.True(t, now.Add(2*time.Hour).Equal(last))
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats, 0)
+}
+
+func TestDBRetentionSkipped(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		LibPath:   String(dir),
+		Retention: Int(2),
+	}
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	for i := 0; i < 3; i++ {
+		err = db.Insert(NewStat("TEST", "").Finish(nil))
+		assert.Nil(t, err)
+	}
+
+	// skipped runs don't push out the real runs
+	for i := 0; i < 5; i++ {
+		err = db.Insert(NewStat("TEST", "").Skip(errAlreadyRunning))
+		assert.Nil(t, err)
+	}
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["TEST"], 4)
+
+	statuses := make(map[Status]int)
+	for _, stat := range stats["TEST"] {
+		statuses[stat.Status]++
+	}
+	assert.Equal(t, 2, statuses[StatusSuccess])
+	assert.Equal(t, 2, statuses[StatusSkipped])
+}
@@ -0,0 +1,116 @@
+package resync
+
+import (
+	"sync"
+)
+
+// EventType is the type of a sync lifecycle event.
+type EventType string
+
+const (
+	// EventStarted is published when a sync starts running.
+	EventStarted EventType = "started"
+
+	// EventQueued is published when a sync waits to run because of max_concurrent or its overlap policy.
+	EventQueued EventType = "queued"
+
+	// EventSkipped is published when a sync is skipped because it's already running.
+	EventSkipped EventType = "skipped"
+
+	// EventFinished is published when a sync finishes successfully.
+	EventFinished EventType = "finished"
+
+	// EventFailed is published when a sync fails.
+	EventFailed EventType = "failed"
+
+	// EventTimedOut is published when a sync is killed because it exceeded its time limit.
+	EventTimedOut EventType = "timed_out"
+
+	// EventCancelled is published when a running sync is cancelled.
+	EventCancelled EventType = "cancelled"
+)
+
+// Event describes a change in the lifecycle of a sync. Stat is the stat for the run that caused the event. For
+// queued and started events the Stat hasn't finished yet.
+type Event struct {
+	Type EventType
+	Name string
+	Stat Stat
+}
+
+// finished returns true if the event ends a run. Queued, started, and skipped events don't.
+func (e Event) finished() bool {
+	switch e.Type {
+	case EventFinished, EventFailed, EventTimedOut, EventCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// newFinishedEvent returns the event that matches how stat finished.
+func newFinishedEvent(stat Stat) Event {
+	event := Event{
+		Type: EventFailed,
+		Name: stat.Name,
+		Stat: stat,
+	}
+
+	switch stat.Status {
+	case StatusSuccess, StatusWarning:
+		event.Type = EventFinished
+	case StatusCancelled:
+		event.Type = EventCancelled
+	case StatusTimedOut:
+		event.Type = EventTimedOut
+	}
+
+	return event
+}
+
+// eventBroker sends published events to every subscriber. Subscribers that can't keep up miss events instead of
+// blocking the publisher.
+type eventBroker struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		mu:   sync.Mutex{},
+		subs: make(map[chan Event]struct{}),
+	}
+}
+
+// subscribe returns a channel that receives published events and a function that unsubscribes and closes the
+// channel.
+func (b *eventBroker) subscribe() (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := make(chan Event, 64)
+	b.subs[c] = struct{}{}
+
+	var once sync.Once
+	return c, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.subs, c)
+			close(c)
+		})
+	}
+}
+
+func (b *eventBroker) publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for c := range b.subs {
+		select {
+		case c <- event:
+		default:
+		}
+	}
+}
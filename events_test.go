@@ -0,0 +1,105 @@
+package resync
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBroker(t *testing.T) {
+	broker := newEventBroker()
+
+	events, unsubscribe := broker.subscribe()
+	broker.publish(Event{Type: EventStarted, Name: "test"})
+
+	event := <-events
+	assert.Equal(t, EventStarted, event.Type)
+	assert.Equal(t, "test", event.Name)
+
+	// slow subscribers miss events instead of blocking
+	for i := 0; i < 100; i++ {
+		broker.publish(Event{Type: EventStarted, Name: "test"})
+	}
+	assert.Len(t, events, 64)
+
+	unsubscribe()
+	unsubscribe()
+	broker.publish(Event{Type: EventStarted, Name: "test"})
+}
+
+func TestFinishedEvent(t *testing.T) {
+	stat := NewStat("test", "").Finish(nil)
+	assert.Equal(t, EventFinished, newFinishedEvent(stat).Type)
+
+	stat = NewStat("test", "").Finish(errors.New("fail"))
+	assert.Equal(t, EventFailed, newFinishedEvent(stat).Type)
+
+	stat.Status = StatusCancelled
+	assert.Equal(t, EventCancelled, newFinishedEvent(stat).Type)
+
+	stat.Status = StatusTimedOut
+	assert.Equal(t, EventTimedOut, newFinishedEvent(stat).Type)
+}
+
+func TestEvents(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		RsyncPath: String("sleep"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("5"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+				TimeLimit:        String("200ms"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	events, unsubscribe := re.Subscribe()
+	defer unsubscribe()
+
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+
+	types := make([]EventType, 0)
+	timeout := time.After(2 * time.Second)
+	for len(types) < 3 {
+		select {
+		case event := <-events:
+			assert.Equal(t, "test", event.Name)
+			assert.Equal(t, "test", event.Stat.Name)
+			types = append(types, event.Type)
+		case <-timeout:
+			t.Fatal("timed out waiting for events")
+		}
+	}
+
+	assert.Contains(t, types, EventStarted)
+	assert.Contains(t, types, EventSkipped)
+	assert.Equal(t, EventTimedOut, types[2])
+}
@@ -0,0 +1,60 @@
+package resync
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// exitCodes maps the exit codes documented in the rsync man page to their meaning.
+var exitCodes = map[int]string{
+	0:  "Success",
+	1:  "Syntax or usage error",
+	2:  "Protocol incompatibility",
+	3:  "Errors selecting input/output files, dirs",
+	4:  "Requested action not supported",
+	5:  "Error starting client-server protocol",
+	6:  "Daemon unable to append to log-file",
+	10: "Error in socket I/O",
+	11: "Error in file I/O",
+	12: "Error in rsync protocol data stream",
+	13: "Errors with program diagnostics",
+	14: "Error in IPC code",
+	20: "Received SIGUSR1 or SIGINT",
+	21: "Some error returned by waitpid()",
+	22: "Error allocating core memory buffers",
+	23: "Partial transfer due to error",
+	24: "Partial transfer due to vanished source files",
+	25: "The --max-delete limit stopped deletions",
+	30: "Timeout in data send/receive",
+	35: "Timeout waiting for daemon connection",
+}
+
+// ExitCodeDescription returns the meaning of an rsync exit code. A code of -1 means rsync didn't exit on its own
+// because it couldn't be started or was killed by a signal.
+func ExitCodeDescription(code int) string {
+	if description, ok := exitCodes[code]; ok {
+		return description
+	}
+
+	if code < 0 {
+		return "Killed or failed to start"
+	}
+
+	return fmt.Sprintf("Unknown exit code %d", code)
+}
+
+// exitCode returns the exit code of the command that returned err. It returns 0 if err is nil and -1 if the
+// command didn't exit on its own.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
@@ -0,0 +1,28 @@
+package resync
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCodeDescription(t *testing.T) {
+	assert.Equal(t, "Success", ExitCodeDescription(0))
+	assert.Equal(t, "Partial transfer due to error", ExitCodeDescription(23))
+	assert.Equal(t, "Partial transfer due to vanished source files", ExitCodeDescription(24))
+	assert.Equal(t, "Unknown exit code 99", ExitCodeDescription(99))
+	assert.Equal(t, "Killed or failed to start", ExitCodeDescription(-1))
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, exitCode(nil))
+	assert.Equal(t, -1, exitCode(errors.New("fail")))
+
+	err := exec.Command("sh", "-c", "exit 24").Run()
+	assert.Equal(t, 24, exitCode(err))
+
+	err = exec.Command("does-not-exist").Run()
+	assert.Equal(t, -1, exitCode(err))
+}
@@ -0,0 +1,75 @@
+package resync
+
+import (
+	"fmt"
+	"time"
+)
+
+// Health returns an error if the sync with name is unhealthy. A sync is unhealthy if its latest run that wasn't
+// cancelled or skipped failed or if max_age is set and its last successful run ended longer than max_age ago. Syncs that have
+// never succeeded are measured from when resync started.
+func (re *Resync) Health(name string) error {
+	if _, err := re.config.GetSync(name); err != nil {
+		return err
+	}
+
+	statMap, err := re.db.List()
+	if err != nil {
+		return err
+	}
+
+	return re.health(name, statMap[name])
+}
+
+// HealthAll returns an error if any of the syncs are unhealthy.
+func (re *Resync) HealthAll() error {
+	statMap, err := re.db.List()
+	if err != nil {
+		return err
+	}
+
+	for name := range re.config.Syncs {
+		if err := re.health(name, statMap[name]); err != nil {
+			return fmt.Errorf("One more more syncs failed including %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// health checks the health of the sync with name using its stats sorted by start in descending order.
+func (re *Resync) health(name string, stats []Stat) error {
+	// cancelled and skipped syncs didn't fail so check the most recent run that wasn't cancelled or skipped
+	for _, stat := range stats {
+		if stat.Status == StatusCancelled || stat.Status == StatusSkipped {
+			continue
+		}
+
+		switch stat.Status {
+		case StatusTimedOut:
+			return fmt.Errorf("Sync %s exceeded its time limit", name)
+		case StatusFailed:
+			return fmt.Errorf("Sync %s failed: %s", name, stat.ExitDescription)
+		}
+		break
+	}
+
+	sync := re.config.Syncs[name]
+	if sync.MaxAge == nil {
+		return nil
+	}
+
+	lastSuccess := re.started
+	for _, stat := range stats {
+		if stat.Success() && !stat.EndTime.IsZero() {
+			lastSuccess = stat.EndTime
+			break
+		}
+	}
+
+	if age := time.Since(lastSuccess); age > sync.maxAge {
+		return fmt.Errorf("Sync %s last succeeded %s ago which exceeds max_age %s", name, age.Round(time.Second), sync.maxAge)
+	}
+
+	return nil
+}
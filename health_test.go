@@ -0,0 +1,92 @@
+package resync
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		LogPath: String(dir),
+		LibPath: String(dir),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+			"aged": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("0 0 1 1 *"),
+				MaxAge:           String("1h"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+	re.started = time.Now()
+
+	err = re.Health("foo")
+	assert.Error(t, err)
+
+	// syncs without stats are healthy
+	assert.Nil(t, re.Health("test"))
+	assert.Nil(t, re.Health("aged"))
+	assert.Nil(t, re.HealthAll())
+
+	// syncs that have never succeeded are measured from when resync started
+	re.started = time.Now().Add(-2 * time.Hour)
+	assert.Error(t, re.Health("aged"))
+	assert.Error(t, re.HealthAll())
+
+	old := NewStat("aged", "").Finish(nil)
+	old.EndTime = time.Now().Add(-90 * time.Minute)
+	err = db.Insert(old)
+	assert.Nil(t, err)
+	assert.Error(t, re.Health("aged"))
+
+	err = db.Insert(NewStat("aged", "").Finish(nil))
+	assert.Nil(t, err)
+	assert.Nil(t, re.Health("aged"))
+
+	err = db.Insert(NewStat("test", "").Finish(errors.New("fail")))
+	assert.Nil(t, err)
+	assert.Error(t, re.Health("test"))
+	assert.Error(t, re.HealthAll())
+
+	// cancelled runs are ignored
+	cancelled := NewStat("test", "").Finish(errors.New("cancelled"))
+	cancelled.Status = StatusCancelled
+	err = db.Insert(cancelled)
+	assert.Nil(t, err)
+	assert.Error(t, re.Health("test"))
+
+	// so are skipped runs
+	err = db.Insert(NewStat("test", "").Skip(errAlreadyRunning))
+	assert.Nil(t, err)
+	assert.Error(t, re.Health("test"))
+
+	err = db.Insert(NewStat("test", "").Finish(nil))
+	assert.Nil(t, err)
+	assert.Nil(t, re.Health("test"))
+	assert.Nil(t, re.HealthAll())
+}
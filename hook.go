@@ -0,0 +1,90 @@
+package resync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The names of the hooks. They're used in logs and in the RESYNC_HOOK environment variable.
+const (
+	hookPre       = "pre_command"
+	hookPost      = "post_command"
+	hookOnSuccess = "on_success_command"
+	hookOnFailure = "on_failure_command"
+)
+
+// hook runs command for the hook using the shell and writes its output to stdout and stderr. The command is stopped
+// the same way as rsync if ctx is done or the hook timeout passes before it exits.
+func (re *Resync) hook(ctx context.Context, hook string, command string, sync *Sync, stat Stat, stdout io.Writer, stderr io.Writer) error {
+	log.Infof("Running %s for %s: %s", hook, stat.Name, command)
+
+	ctx, cancel := context.WithTimeout(ctx, re.config.GetHookTimeout(stat.Name))
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), hookEnv(hook, sync, stat)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := runCommand(ctx, cmd, re.config.GetGracePeriod(stat.Name)); err != nil {
+		log.Errorf("Error %s for %s: %s", hook, stat.Name, err)
+		return fmt.Errorf("%s failed: %w", hook, err)
+	}
+	return nil
+}
+
+// postHooks runs the hooks that follow rsync. They always run so they can clean up after a failed, cancelled, or
+// timed out run, so they're only limited by the hook timeout. Failures are logged but don't change the result of the
+// run.
+func (re *Resync) postHooks(sync *Sync, stat Stat, stdout io.Writer, stderr io.Writer) {
+	if stat.Success() && sync.OnSuccessCommand != nil {
+		re.hook(context.Background(), hookOnSuccess, StringValue(sync.OnSuccessCommand), sync, stat, stdout, stderr)
+	}
+
+	if !stat.Success() && sync.OnFailureCommand != nil {
+		re.hook(context.Background(), hookOnFailure, StringValue(sync.OnFailureCommand), sync, stat, stdout, stderr)
+	}
+
+	if sync.PostCommand != nil {
+		re.hook(context.Background(), hookPost, StringValue(sync.PostCommand), sync, stat, stdout, stderr)
+	}
+}
+
+// hookEnv returns the environment variables that describe the sync and its result to a hook. The result is only
+// included for hooks that run after rsync.
+func hookEnv(hook string, sync *Sync, stat Stat) []string {
+	env := []string{
+		"RESYNC_HOOK=" + hook,
+		"RESYNC_NAME=" + stat.Name,
+		"RESYNC_SOURCE=" + strings.Join(sync.RsyncSource, " "),
+		"RESYNC_DESTINATION=" + StringValue(sync.RsyncDestination),
+		"RESYNC_START=" + stat.Start,
+	}
+
+	if hook == hookPre {
+		return env
+	}
+
+	return append(env,
+		"RESYNC_STATUS="+string(stat.Status),
+		"RESYNC_SUCCESS="+strconv.FormatBool(stat.Success()),
+		"RESYNC_EXIT_CODE="+strconv.Itoa(stat.ExitCode),
+		"RESYNC_END_REASON="+stat.EndReason,
+		"RESYNC_ERROR="+stat.Error,
+		"RESYNC_END="+stat.End,
+		"RESYNC_DURATION="+strconv.FormatFloat(stat.Duration.Seconds(), 'f', 3, 64),
+	)
+}
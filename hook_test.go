@@ -0,0 +1,144 @@
+package resync
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooks(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "hooks")
+
+	re, cleanup := newTestResync(t, &Config{
+		RsyncPath: String("sh"),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-c"),
+				RsyncSource:      []string{"echo rsync >> " + out},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+				PreCommand:       String(`echo "pre $RESYNC_HOOK $RESYNC_NAME $RESYNC_STATUS" >> ` + out + `; echo pre output`),
+				OnSuccessCommand: String(`echo "success $RESYNC_STATUS $RESYNC_EXIT_CODE" >> ` + out),
+				OnFailureCommand: String(`echo failure >> ` + out),
+				PostCommand:      String(`echo "post $RESYNC_SUCCESS $RESYNC_DESTINATION" >> ` + out),
+			},
+		},
+	})
+	defer cleanup()
+
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	b, err := os.ReadFile(out)
+	assert.Nil(t, err)
+	assert.Equal(t, "pre pre_command test \nrsync\nsuccess success 0\npost true 0\n", string(b))
+
+	// hook output is written to the sync's logs
+	stdout, err := re.logger.Stdout("test")
+	assert.Nil(t, err)
+	defer stdout.Close()
+
+	b, err = io.ReadAll(stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "pre output\n", string(b))
+
+	stats, err := re.db.List()
+	assert.Nil(t, err)
+	assert.True(t, stats["test"][0].Success())
+}
+
+func TestPreHookFailure(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "hooks")
+
+	re, cleanup := newTestResync(t, &Config{
+		RsyncPath: String("sh"),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-c"),
+				RsyncSource:      []string{"echo rsync >> " + out},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+				PreCommand:       String("echo snapshot failed >&2; exit 3"),
+				OnFailureCommand: String(`echo "failure $RESYNC_STATUS $RESYNC_EXIT_CODE $RESYNC_END_REASON" >> ` + out),
+				PostCommand:      String(`echo post >> ` + out),
+			},
+		},
+	})
+	defer cleanup()
+
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	// rsync isn't run but the post hooks are
+	b, err := os.ReadFile(out)
+	assert.Nil(t, err)
+	assert.Equal(t, "failure failed 0 pre_command_failed\npost\n", string(b))
+
+	stats, err := re.db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["test"], 1)
+	assert.False(t, stats["test"][0].Success())
+	assert.Equal(t, 0, stats["test"][0].ExitCode)
+	assert.False(t, stats["test"][0].RsyncRan())
+	assert.Equal(t, EndPreCommandFailed, stats["test"][0].EndReason)
+	assert.Equal(t, "pre_command failed", stats["test"][0].ExitDescription)
+	assert.Equal(t, "pre_command failed: exit status 3", stats["test"][0].Error)
+	assert.Len(t, stats["test"][0].Attempts, 0)
+
+	stderr, err := re.logger.Stderr("test")
+	assert.Nil(t, err)
+	defer stderr.Close()
+
+	b, err = io.ReadAll(stderr)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(b), "snapshot failed\n"))
+}
+
+func TestHookTimeoutRun(t *testing.T) {
+	re, cleanup := newTestResync(t, &Config{
+		RsyncPath: String("sh"),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-c"),
+				RsyncSource:      []string{"exit 0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+				HookTimeout:      String("200ms"),
+				GracePeriod:      String("100ms"),
+				OnSuccessCommand: String("trap '' TERM; sleep 10"),
+				PostCommand:      String("sleep 10"),
+			},
+		},
+	})
+	defer cleanup()
+
+	start := time.Now()
+	err := re.sync("test")
+	assert.Nil(t, err)
+
+	// each hook is stopped after the hook timeout and killed if it ignores SIGTERM
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	status, err := re.Status("test")
+	assert.Nil(t, err)
+	assert.False(t, status.Running)
+	assert.Equal(t, 1, status.Runs)
+	assert.True(t, status.LastStat.Success())
+}
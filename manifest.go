@@ -0,0 +1,199 @@
+package resync
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Change is the type of change rsync made to a path.
+type Change string
+
+const (
+	// ChangeCreated is a path that didn't exist in the destination.
+	ChangeCreated Change = "created"
+
+	// ChangeUpdated is a path whose contents were transferred or whose link target changed.
+	ChangeUpdated Change = "updated"
+
+	// ChangeDeleted is a path that was deleted from the destination.
+	ChangeDeleted Change = "deleted"
+
+	// ChangeAttributes is a path where only attributes such as the time or permissions changed.
+	ChangeAttributes Change = "attributes"
+)
+
+// ManifestEntry is a single path changed by rsync. Item is the itemized change string printed by rsync such as
+// >f.st...... or *deleting.
+type ManifestEntry struct {
+	Change Change
+	Path   string
+	Item   string
+}
+
+// shortArgOptions are the rsync short options that take a value. The rest of a short option cluster after one of them
+// is its value.
+const shortArgOptions = "eBfTM@"
+
+// hasItemizeArg returns true if args already itemize changes either with --itemize-changes or with i in a cluster of
+// short options like -avi. Adding --itemize-changes again would make rsync list unchanged paths too.
+func hasItemizeArg(args []string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			if strings.HasPrefix(arg, "--itemize") {
+				return true
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		for _, c := range arg[1:] {
+			if c == 'i' {
+				return true
+			}
+			if strings.ContainsRune(shortArgOptions, c) {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// Manifest is every path changed during a single run of a sync. It's stored using the same Start as the run's
+// Stat.
+type Manifest struct {
+	Name      string
+	Start     string
+	StartTime time.Time
+	Entries   []ManifestEntry
+}
+
+// NewManifest creates an empty Manifest for stat.
+func NewManifest(stat Stat) Manifest {
+	return Manifest{
+		Name:      stat.Name,
+		Start:     stat.Start,
+		StartTime: stat.StartTime,
+		Entries:   make([]ManifestEntry, 0),
+	}
+}
+
+// Filter returns a copy of the manifest with only the entries for path. Paths match if they're equal ignoring a
+// leading ./ or a trailing /, or if the entry is inside path when path is a directory. An empty path matches every
+// entry. If change isn't empty then only entries with that change are returned.
+func (m Manifest) Filter(path string, change Change) Manifest {
+	path = cleanManifestPath(path)
+
+	entries := make([]ManifestEntry, 0)
+	for _, entry := range m.Entries {
+		if change != "" && entry.Change != change {
+			continue
+		}
+
+		if path != "" {
+			entryPath := cleanManifestPath(entry.Path)
+			if entryPath != path && !strings.HasPrefix(entryPath, path+"/") {
+				continue
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	m.Entries = entries
+	return m
+}
+
+func cleanManifestPath(path string) string {
+	path = strings.TrimPrefix(path, "./")
+	return strings.TrimSuffix(path, "/")
+}
+
+// parseItemizedLine returns the entry for a single line of rsync --itemize-changes output. It returns false if the
+// line isn't an itemized change or the path didn't change.
+func parseItemizedLine(line string) (ManifestEntry, bool) {
+	if strings.HasPrefix(line, "*deleting ") {
+		return ManifestEntry{
+			Change: ChangeDeleted,
+			Path:   strings.TrimSpace(strings.TrimPrefix(line, "*deleting ")),
+			Item:   "*deleting",
+		}, true
+	}
+
+	// itemized changes look like YXcstpoguax followed by a space and the path
+	i := strings.IndexByte(line, ' ')
+	if i < 2 || i+1 >= len(line) {
+		return ManifestEntry{}, false
+	}
+
+	item, path := line[:i], line[i+1:]
+	if !strings.ContainsRune("<>ch.", rune(item[0])) || !strings.ContainsRune("fdLDS", rune(item[1])) {
+		return ManifestEntry{}, false
+	}
+
+	// the path of links is followed by the link target
+	if item[1] == 'L' || item[0] == 'h' {
+		if j := strings.Index(path, " -> "); j >= 0 {
+			path = path[:j]
+		} else if j := strings.Index(path, " => "); j >= 0 {
+			path = path[:j]
+		}
+	}
+
+	entry := ManifestEntry{
+		Path: path,
+		Item: item,
+	}
+
+	attributes := item[2:]
+	switch {
+	case strings.HasPrefix(attributes, "+"):
+		entry.Change = ChangeCreated
+	case item[0] != '.':
+		entry.Change = ChangeUpdated
+	case strings.Trim(attributes, ". ") != "":
+		entry.Change = ChangeAttributes
+	default:
+		// unchanged paths are only printed when --itemize-changes is repeated
+		return ManifestEntry{}, false
+	}
+
+	return entry, true
+}
+
+// Manifests returns the stored manifests for the sync with name sorted by Start in descending order. If path or
+// change are set then the manifests are filtered using Manifest.Filter and manifests without a matching entry are
+// left out. This answers questions like which run deleted a path.
+func (re *Resync) Manifests(name string, path string, change Change) ([]Manifest, error) {
+	if _, err := re.config.GetSync(name); err != nil {
+		return nil, err
+	}
+
+	switch change {
+	case "", ChangeCreated, ChangeUpdated, ChangeDeleted, ChangeAttributes:
+	default:
+		return nil, fmt.Errorf("Invalid change: %s", change)
+	}
+
+	manifests, err := re.db.ListManifests(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" && change == "" {
+		return manifests, nil
+	}
+
+	filtered := make([]Manifest, 0)
+	for _, manifest := range manifests {
+		manifest = manifest.Filter(path, change)
+		if len(manifest.Entries) > 0 {
+			filtered = append(filtered, manifest)
+		}
+	}
+
+	return filtered, nil
+}
@@ -0,0 +1,140 @@
+package resync
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseItemizedLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		ok     bool
+		change Change
+		path   string
+	}{
+		{"*deleting   data/x", true, ChangeDeleted, "data/x"},
+		{"cd+++++++++ data/new/", true, ChangeCreated, "data/new/"},
+		{">f+++++++++ data/new/file name", true, ChangeCreated, "data/new/file name"},
+		{"<f+++++++++ remote", true, ChangeCreated, "remote"},
+		{">f.st...... data/changed", true, ChangeUpdated, "data/changed"},
+		{".f...p..... data/mode", true, ChangeAttributes, "data/mode"},
+		{".d..t...... data/", true, ChangeAttributes, "data/"},
+		{"cL+++++++++ data/link -> file", true, ChangeCreated, "data/link"},
+		{"cL.st...... data/link -> other", true, ChangeUpdated, "data/link"},
+		{"hf+++++++++ data/hard => data/file", true, ChangeCreated, "data/hard"},
+		{".f          data/unchanged", false, "", ""},
+		{"sending incremental file list", false, "", ""},
+		{"total size is 2,048  speedup is 1.66", false, "", ""},
+		{"", false, "", ""},
+	}
+
+	for _, test := range tests {
+		entry, ok := parseItemizedLine(test.line)
+		assert.Equal(t, test.ok, ok, test.line)
+		assert.Equal(t, test.change, entry.Change, test.line)
+		assert.Equal(t, test.path, entry.Path, test.line)
+	}
+}
+
+func TestManifestFilter(t *testing.T) {
+	manifest := Manifest{
+		Name: "test",
+		Entries: []ManifestEntry{
+			{Change: ChangeDeleted, Path: "data/x"},
+			{Change: ChangeCreated, Path: "data/xy"},
+			{Change: ChangeCreated, Path: "data/new/"},
+			{Change: ChangeCreated, Path: "data/new/file"},
+		},
+	}
+
+	assert.Len(t, manifest.Filter("", "").Entries, 4)
+	assert.Len(t, manifest.Filter("data/x", "").Entries, 1)
+	assert.Len(t, manifest.Filter("./data/x", ChangeDeleted).Entries, 1)
+	assert.Len(t, manifest.Filter("data/x", ChangeCreated).Entries, 0)
+	assert.Len(t, manifest.Filter("data/new", "").Entries, 2)
+	assert.Len(t, manifest.Filter("data", ChangeCreated).Entries, 3)
+
+	// filtering doesn't change the original manifest
+	assert.Len(t, manifest.Entries, 4)
+}
+
+func TestManifests(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		RsyncPath: String("./testdata/itemize.sh"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+				Manifest:         Bool(true),
+			},
+			"other": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+	_, err = re.Run("other")
+	assert.Nil(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	_, err = re.Manifests("foo", "", "")
+	assert.Error(t, err)
+
+	_, err = re.Manifests("test", "", "invalid")
+	assert.Error(t, err)
+
+	manifests, err := re.Manifests("other", "", "")
+	assert.Nil(t, err)
+	assert.Len(t, manifests, 0)
+
+	manifests, err = re.Manifests("test", "", "")
+	assert.Nil(t, err)
+	assert.Len(t, manifests, 1)
+	assert.Len(t, manifests[0].Entries, 7)
+
+	manifests, err = re.Manifests("test", "data/x", ChangeDeleted)
+	assert.Nil(t, err)
+	assert.Len(t, manifests, 1)
+	assert.Equal(t, []ManifestEntry{{Change: ChangeDeleted, Path: "data/x", Item: "*deleting"}}, manifests[0].Entries)
+
+	// manifests aren't listed as stats and share the start of their stat
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats, 2)
+	assert.True(t, stats["test"][0].StartTime.Equal(manifests[0].StartTime))
+
+	manifests, err = re.Manifests("test", "data/missing", "")
+	assert.Nil(t, err)
+	assert.Len(t, manifests, 0)
+}
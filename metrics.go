@@ -0,0 +1,118 @@
+package resync
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// metric is a single Prometheus metric family with one value per sync.
+type metric struct {
+	name   string
+	help   string
+	typ    string
+	values map[string]float64
+}
+
+// WriteMetrics writes per sync metrics to w using the Prometheus text exposition format. Run counters come from
+// Resync and are reset when resync starts. Timestamps and durations come from the stats stored in the DB.
+func (re *Resync) WriteMetrics(w io.Writer) error {
+	statMap, err := re.db.List()
+	if err != nil {
+		return err
+	}
+
+	statuses, err := re.statuses(statMap)
+	if err != nil {
+		return err
+	}
+
+	lastStart := newMetric("resync_sync_last_start_timestamp_seconds", "Unix time the last run of the sync started.", "gauge")
+	lastEnd := newMetric("resync_sync_last_end_timestamp_seconds", "Unix time the last run of the sync ended.", "gauge")
+	lastSuccess := newMetric("resync_sync_last_success_timestamp_seconds", "Unix time the last successful run of the sync ended.", "gauge")
+	lastDuration := newMetric("resync_sync_last_duration_seconds", "Duration of the last run of the sync.", "gauge")
+	lastExitCode := newMetric("resync_sync_last_exit_code", "Exit code of rsync for the last run of the sync.", "gauge")
+	lastFiles := newMetric("resync_sync_last_files_transferred", "Number of files transferred by the last run of the sync. Requires --stats.", "gauge")
+	lastTotalSize := newMetric("resync_sync_last_total_size_bytes", "Total size of the source files for the last run of the sync. Requires --stats.", "gauge")
+	lastTransferredSize := newMetric("resync_sync_last_transferred_size_bytes", "Total size of the files transferred by the last run of the sync. Requires --stats.", "gauge")
+	running := newMetric("resync_sync_running", "Whether the sync is currently running.", "gauge")
+	queued := newMetric("resync_sync_queued", "Whether the sync is waiting to run because of max_concurrent.", "gauge")
+	runs := newMetric("resync_sync_runs_total", "Number of finished runs of the sync since resync started.", "counter")
+	failures := newMetric("resync_sync_failures_total", "Number of failed runs of the sync since resync started.", "counter")
+	timedOut := newMetric("resync_sync_time_limit_kills_total", "Number of runs of the sync killed by the time limit since resync started.", "counter")
+	skipped := newMetric("resync_sync_skipped_total", "Number of runs of the sync skipped because it was already running since resync started.", "counter")
+
+	for _, status := range statuses {
+		running.values[status.Name] = boolFloat(status.Running)
+		queued.values[status.Name] = boolFloat(status.Queued)
+		runs.values[status.Name] = float64(status.Runs)
+		failures.values[status.Name] = float64(status.Failures)
+		timedOut.values[status.Name] = float64(status.TimedOut)
+		skipped.values[status.Name] = float64(status.Skipped)
+
+		if status.LastStat != nil && !status.LastStat.StartTime.IsZero() {
+			lastStart.values[status.Name] = unixSeconds(status.LastStat.StartTime)
+			lastEnd.values[status.Name] = unixSeconds(status.LastStat.EndTime)
+			lastDuration.values[status.Name] = status.LastStat.Duration.Seconds()
+			if status.LastStat.RsyncRan() {
+				lastExitCode.values[status.Name] = float64(status.LastStat.ExitCode)
+			}
+			lastFiles.values[status.Name] = float64(status.LastStat.FilesTransferred)
+			lastTotalSize.values[status.Name] = float64(status.LastStat.TotalSize)
+			lastTransferredSize.values[status.Name] = float64(status.LastStat.TransferredSize)
+		}
+
+		for _, stat := range statMap[status.Name] {
+			if stat.Success() && !stat.EndTime.IsZero() {
+				lastSuccess.values[status.Name] = unixSeconds(stat.EndTime)
+				break
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, m := range []*metric{lastStart, lastEnd, lastSuccess, lastDuration, lastExitCode, lastFiles, lastTotalSize, lastTransferredSize, running, queued, runs, failures, timedOut, skipped} {
+		m.write(bw, statuses)
+	}
+
+	return bw.Flush()
+}
+
+func newMetric(name, help, typ string) *metric {
+	return &metric{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		values: make(map[string]float64),
+	}
+}
+
+// write writes the metric family in the same order as statuses.
+func (m *metric) write(w io.Writer, statuses []SyncStatus) {
+	fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.typ)
+
+	for _, status := range statuses {
+		if v, ok := m.values[status.Name]; ok {
+			fmt.Fprintf(w, "%s{sync=\"%s\"} %g\n", m.name, escapeLabel(status.Name), v)
+		}
+	}
+}
+
+// escapeLabel escapes a Prometheus label value.
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package resync
+
+import (
+	"bytes"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// listCountDB counts the calls to List.
+type listCountDB struct {
+	DB
+	lists int32
+}
+
+func (db *listCountDB) List() (map[string][]Stat, error) {
+	atomic.AddInt32(&db.lists, 1)
+	return db.DB.List()
+}
+
+func TestMetrics(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		RsyncPath: String("sleep"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"fast": {
+				RsyncArgs:        String("0"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+			"slow": {
+				RsyncArgs:        String("5"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+				TimeLimit:        String("100ms"),
+			},
+			"pre": {
+				RsyncArgs:        String("0"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+				PreCommand:       String("exit 3"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	bolt, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer bolt.Close()
+	db := &listCountDB{DB: bolt}
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.WriteMetrics(&bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	_, err = re.Run("fast")
+	assert.Nil(t, err)
+	_, err = re.Run("slow")
+	assert.Nil(t, err)
+	_, err = re.Run("pre")
+	assert.Nil(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	// the stats are only read once per scrape
+	atomic.StoreInt32(&db.lists, 0)
+	buf := &bytes.Buffer{}
+	err = re.WriteMetrics(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&db.lists))
+
+	out := buf.String()
+	assert.Contains(t, out, "# TYPE resync_sync_runs_total counter\n")
+	assert.Contains(t, out, `resync_sync_runs_total{sync="fast"} 1`)
+	assert.Contains(t, out, `resync_sync_runs_total{sync="slow"} 1`)
+	assert.Contains(t, out, `resync_sync_failures_total{sync="fast"} 0`)
+	assert.Contains(t, out, `resync_sync_failures_total{sync="slow"} 1`)
+	assert.Contains(t, out, `resync_sync_time_limit_kills_total{sync="fast"} 0`)
+	assert.Contains(t, out, `resync_sync_time_limit_kills_total{sync="slow"} 1`)
+	assert.Contains(t, out, `resync_sync_running{sync="fast"} 0`)
+	assert.Contains(t, out, `resync_sync_last_success_timestamp_seconds{sync="fast"}`)
+	assert.NotContains(t, out, `resync_sync_last_success_timestamp_seconds{sync="slow"}`)
+	assert.Contains(t, out, `resync_sync_last_duration_seconds{sync="slow"}`)
+	assert.Contains(t, out, `resync_sync_last_exit_code{sync="fast"} 0`)
+	assert.Contains(t, out, `resync_sync_last_exit_code{sync="slow"} -1`)
+
+	// pre_command's exit code isn't reported as an rsync exit code
+	assert.Contains(t, out, `resync_sync_failures_total{sync="pre"} 1`)
+	assert.NotContains(t, out, `resync_sync_last_exit_code{sync="pre"}`)
+}
+
+func TestEscapeLabel(t *testing.T) {
+	assert.Equal(t, `a\\b\"c\nd`, escapeLabel("a\\b\"c\nd"))
+}
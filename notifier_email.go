@@ -44,10 +44,29 @@ func (m *EmailNotifier) Notify(stat Stat) error {
 	message.SetHeader("From", StringValue(m.config.Email.From))
 	message.SetHeader("To", m.config.Email.To...)
 
-	if stat.Success {
+	switch stat.Status {
+	case StatusSkipped:
+		// the logs belong to the run that's still going so there's nothing to attach
+		message.SetHeader("Subject", fmt.Sprintf("Resync: Sync %s Skipped", stat.Name))
+		message.SetBody("text/plain", fmt.Sprintf("%s at %s\n", stat.ExitDescription, stat.Start))
+		return m.send(message)
+	case StatusSuccess:
 		message.SetHeader("Subject", fmt.Sprintf("Resync: Sync %s Complete", stat.Name))
-	} else {
+	case StatusWarning:
+		message.SetHeader("Subject", fmt.Sprintf("Resync: Sync %s Complete With Warning", stat.Name))
+		message.SetBody("text/plain", fmt.Sprintf("rsync exited with code %d: %s\n", stat.ExitCode, stat.ExitDescription))
+	case StatusTimedOut:
+		message.SetHeader("Subject", fmt.Sprintf("Resync: Sync %s Timed Out", stat.Name))
+		message.SetBody("text/plain", fmt.Sprintf("The sync exceeded its time limit after %s\n\n%s\n", stat.Duration, stat.Error))
+	case StatusCancelled:
+		message.SetHeader("Subject", fmt.Sprintf("Resync: Sync %s Cancelled", stat.Name))
+	default:
 		message.SetHeader("Subject", fmt.Sprintf("Resync: Sync %s Failed", stat.Name))
+		if stat.RsyncRan() {
+			message.SetBody("text/plain", fmt.Sprintf("rsync exited with code %d: %s\n\n%s\n", stat.ExitCode, stat.ExitDescription, stat.Error))
+		} else {
+			message.SetBody("text/plain", fmt.Sprintf("%s\n\n%s\n", stat.ExitDescription, stat.Error))
+		}
 	}
 
 	message.Attach("stdout.log", gomail.SetCopyFunc(func(w io.Writer) error {
@@ -216,6 +235,11 @@ var emailTemplate = `<html>
   color: #D32F2F !important;
   font-weight: bold;
 }
+
+.warning {
+  color: #EF6C00 !important;
+  font-weight: bold;
+}
 </style>
 
 </head>
@@ -225,7 +249,7 @@ var emailTemplate = `<html>
 <table class="tg">
         <thead>
                 <tr>
-                        <th class="tg-title" colspan="6">{{$name}}</th>
+                        <th class="tg-title" colspan="9">{{$name}}</th>
                 </tr>
         </thead>
         <tbody>
@@ -234,17 +258,35 @@ var emailTemplate = `<html>
                         <td class="tg-header">Start</td>
                         <td class="tg-header">End</td>
                         <td class="tg-header">Duration</td>
+                        <td class="tg-header">Files</td>
+                        <td class="tg-header">Transferred</td>
+                        <td class="tg-header">Size</td>
+                        <td class="tg-header">Transferred Size</td>
+                        <td class="tg-header">Speedup</td>
                 </tr>
                 {{ range $stats}}
                 <tr>
-                        {{if .Success}}
+                        {{if eq .Status "warning"}}
+                          <td class="warning" title="{{.ExitDescription}}">Warning</td>
+                        {{else if eq .Status "success"}}
                           <td class="success">Success</td>
+                        {{else if eq .Status "skipped"}}
+                          <td class="tg-data" title="{{.ExitDescription}}">Skipped</td>
+                        {{else if eq .Status "cancelled"}}
+                          <td class="tg-data" title="{{.EndReason}}">Cancelled</td>
+                        {{else if eq .Status "timed_out"}}
+                          <td class="failure">Timed Out</td>
                         {{else}}
-                          <td class="failure">Failed</td>
+                          <td class="failure" title="{{.ExitDescription}}">Failed</td>
                         {{end}}
                         <td class="tg-data">{{.Start}}</td>
                         <td class="tg-data">{{.End}}</td>
                         <td class="tg-data">{{.Duration}}</td>
+                        <td class="tg-data">{{.FilesConsidered}}</td>
+                        <td class="tg-data">{{.FilesTransferred}}</td>
+                        <td class="tg-data">{{.TotalSize}}</td>
+                        <td class="tg-data">{{.TransferredSize}}</td>
+                        <td class="tg-data">{{printf "%.2f" .Speedup}}</td>
                 </tr>
                 {{ end}}
         </tbody>
@@ -0,0 +1,114 @@
+package resync
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Progress is the latest progress printed by rsync for a running sync when rsync_args contains --info=progress2.
+// Rate is in bytes per second and ETA is the time rsync estimates is left. Updated is when the progress was
+// printed so a sync that stopped making progress can be spotted.
+type Progress struct {
+	Bytes   ByteSize
+	Percent int
+	Rate    float64
+	ETA     time.Duration
+	Updated time.Time
+}
+
+// hasProgressArg returns true if args tells rsync to print the progress of the whole transfer.
+func hasProgressArg(args []string) bool {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--info=") {
+			continue
+		}
+
+		for _, flag := range strings.Split(strings.TrimPrefix(arg, "--info="), ",") {
+			if strings.EqualFold(flag, "progress2") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseProgressLine returns the progress from a single line of rsync --info=progress2 output such as
+// "1,234,567  45%  12.34MB/s  0:01:23 (xfr#12, to-chk=100/2000)". It returns false if the line isn't progress.
+func parseProgressLine(line string) (Progress, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || !strings.HasSuffix(fields[1], "%") || !strings.HasSuffix(fields[2], "/s") {
+		return Progress{}, false
+	}
+
+	bytes, ok := parseNumber(fields[0])
+	if !ok {
+		return Progress{}, false
+	}
+
+	percent, err := strconv.Atoi(strings.TrimSuffix(fields[1], "%"))
+	if err != nil {
+		return Progress{}, false
+	}
+
+	rate, ok := parseRate(fields[2])
+	if !ok {
+		return Progress{}, false
+	}
+
+	eta, ok := parseClock(fields[3])
+	if !ok {
+		return Progress{}, false
+	}
+
+	return Progress{
+		Bytes:   ByteSize(bytes),
+		Percent: percent,
+		Rate:    rate,
+		ETA:     eta,
+		Updated: time.Now(),
+	}, true
+}
+
+// parseRate parses a transfer rate printed by rsync such as 12.34MB/s into bytes per second.
+func parseRate(s string) (float64, bool) {
+	s = strings.ReplaceAll(strings.TrimSuffix(s, "/s"), ",", "")
+
+	multiplier := 1.0
+	for i, unit := range []string{"kB", "MB", "GB", "TB", "PB"} {
+		if strings.HasSuffix(s, unit) {
+			s = strings.TrimSuffix(s, unit)
+			for j := 0; j <= i; j++ {
+				multiplier *= 1024
+			}
+			break
+		}
+	}
+	s = strings.TrimSuffix(s, "B")
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v * multiplier, true
+}
+
+// parseClock parses a duration printed by rsync as h:mm:ss or d:hh:mm:ss.
+func parseClock(s string) (time.Duration, bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 4 {
+		return 0, false
+	}
+
+	units := []time.Duration{time.Second, time.Minute, time.Hour, 24 * time.Hour}
+
+	var d time.Duration
+	for i := range parts {
+		v, err := strconv.Atoi(parts[len(parts)-1-i])
+		if err != nil {
+			return 0, false
+		}
+		d += time.Duration(v) * units[i]
+	}
+	return d, true
+}
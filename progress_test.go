@@ -0,0 +1,107 @@
+package resync
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProgressLine(t *testing.T) {
+	progress, ok := parseProgressLine("      1,048,576  45%    1.50MB/s    0:01:23 (xfr#12, to-chk=100/2000)")
+	assert.True(t, ok)
+	assert.Equal(t, ByteSize(1048576), progress.Bytes)
+	assert.Equal(t, 45, progress.Percent)
+	assert.Equal(t, 1.5*1024*1024, progress.Rate)
+	assert.Equal(t, time.Minute+23*time.Second, progress.ETA)
+	assert.False(t, progress.Updated.IsZero())
+
+	progress, ok = parseProgressLine("  0   0%    0.00kB/s    0:00:00")
+	assert.True(t, ok)
+	assert.Equal(t, 0, progress.Percent)
+
+	progress, ok = parseProgressLine("  1.23G  99%  512.00kB/s  1:02:03:04 (xfr#1, ir-chk=1000/1001)")
+	assert.True(t, ok)
+	assert.Equal(t, ByteSize(1230000000), progress.Bytes)
+	assert.Equal(t, 512.0*1024, progress.Rate)
+	assert.Equal(t, 26*time.Hour+3*time.Minute+4*time.Second, progress.ETA)
+
+	for _, line := range []string{
+		"sending incremental file list",
+		"data/file",
+		"Number of files: 2 (reg: 1, dir: 1)",
+		"sent 1,200 bytes  received 35 bytes  2,470.00 bytes/sec",
+		"1,024 abc% 1.00MB/s 0:00:01",
+	} {
+		_, ok := parseProgressLine(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestHasProgressArg(t *testing.T) {
+	assert.True(t, hasProgressArg([]string{"-a", "--info=progress2"}))
+	assert.True(t, hasProgressArg([]string{"-a", "--info=stats2,progress2"}))
+	assert.False(t, hasProgressArg([]string{"-a", "--progress"}))
+	assert.False(t, hasProgressArg([]string{"-a", "--info=stats2"}))
+}
+
+func TestProgress(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		RsyncPath: String("./testdata/progress.sh"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a --info=progress2"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	status, err := re.Status("test")
+	assert.Nil(t, err)
+	assert.Nil(t, status.Progress)
+
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	status, err = re.Status("test")
+	assert.Nil(t, err)
+	assert.True(t, status.Running)
+	if assert.NotNil(t, status.Progress) {
+		assert.Equal(t, 45, status.Progress.Percent)
+		assert.Equal(t, ByteSize(1048576), status.Progress.Bytes)
+		assert.Equal(t, time.Minute+23*time.Second, status.Progress.ETA)
+	}
+
+	time.Sleep(time.Second)
+
+	// progress is only reported while the sync is running
+	status, err = re.Status("test")
+	assert.Nil(t, err)
+	assert.False(t, status.Running)
+	assert.Nil(t, status.Progress)
+}
@@ -2,23 +2,76 @@ package resync
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"io"
+	"math/big"
 	"os"
 	"os/exec"
 	"runtime/debug"
 	"strings"
+	"sync/atomic"
 	"text/tabwriter"
+	"time"
 
 	cron "github.com/robfig/cron/v3"
 	log "github.com/sirupsen/logrus"
 )
 
-// runningSync is used to pass sync information on a channel
+// ErrNotRunning is returned when a sync is requested while Resync isn't running.
+var ErrNotRunning = errors.New("Resync isn't running")
+
+// errors sent on runningc by the main loop when a sync is skipped
+var (
+	errAlreadyRunning = errors.New("it's already running")
+	errPaused         = errors.New("it's paused")
+	errOutsideWindow  = errors.New("it's outside of its allowed windows")
+)
+
+// runningSync is used to pass sync information on a channel. Queued is true while the sync waits for a free slot
+// and startedc is closed when it leaves the queue. Next is the run of the same sync that starts after this one
+// finishes because of the sync's overlap policy. They're only changed by the main loop. Reason is why the sync was
+// stopped before it finished on its own.
 type runningSync struct {
-	name     string
-	cancel   context.CancelFunc
-	runningc chan bool
+	name      string
+	scheduled bool
+	ctx       context.Context
+	cancel    context.CancelFunc
+	runningc  chan error
+	startedc  chan struct{}
+	queued    bool
+	queuedAt  time.Time
+	next      *runningSync
+	reason    atomic.Value
+	stat      *Stat
+	progress  atomic.Value
+	rotated   int32
+}
+
+// stop cancels the sync and records reason as why it ended. Reason is one of the End constants.
+func (rc *runningSync) stop(reason string) {
+	rc.reason.Store(reason)
+	rc.cancel()
+}
+
+// stopped returns why the sync was stopped or an empty string if it wasn't.
+func (rc *runningSync) stopped() string {
+	reason, _ := rc.reason.Load().(string)
+	return reason
+}
+
+// updateProgress sets the progress of the sync if line is an rsync progress update.
+func (rc *runningSync) updateProgress(line string) {
+	if progress, ok := parseProgressLine(line); ok {
+		rc.progress.Store(progress)
+	}
+}
+
+// syncRequest is used to ask the main loop to act on a sync by name
+type syncRequest struct {
+	name   string
+	replyc chan bool
 }
 
 // Resync is responsible for running rsync commands based on cron schedules.
@@ -27,29 +80,57 @@ type Resync struct {
 	db       DB
 	logger   Logger
 	notifier Notifier
+	events   *eventBroker
 	crontab  *cron.Cron
+	entries  map[string]cron.EntryID
 	syncs    map[string]*runningSync
+	counters map[string]*SyncStatus
+	paused   map[string]bool
+	depsDone map[string]map[string]bool
+	queue    []*runningSync
 	running  bool
 	stopping bool
+	started  time.Time
 	startc   chan *runningSync
 	endc     chan *runningSync
+	cancelc  chan *syncRequest
+	pausec   chan *syncRequest
+	resumec  chan *syncRequest
+	statec   chan chan map[string]SyncStatus
+	rotatedc chan *syncRequest
 	stopc    chan struct{}
 	donec    chan struct{}
+	exitc    chan struct{}
 }
 
 // New creates a new Resync object.
 func New(config *Config, db DB, logger Logger, notifier Notifier) *Resync {
+	// exitc is closed whenever the main loop isn't running
+	exitc := make(chan struct{})
+	close(exitc)
+
 	return &Resync{
 		config:   config,
 		db:       db,
 		logger:   logger,
 		notifier: notifier,
+		events:   newEventBroker(),
 		syncs:    make(map[string]*runningSync),
+		counters: make(map[string]*SyncStatus),
+		paused:   make(map[string]bool),
+		depsDone: make(map[string]map[string]bool),
 		crontab:  cron.New(),
+		entries:  make(map[string]cron.EntryID),
 		startc:   make(chan *runningSync),
 		endc:     make(chan *runningSync),
+		cancelc:  make(chan *syncRequest),
+		pausec:   make(chan *syncRequest),
+		resumec:  make(chan *syncRequest),
+		statec:   make(chan chan map[string]SyncStatus),
+		rotatedc: make(chan *syncRequest),
 		stopc:    make(chan struct{}),
 		donec:    make(chan struct{}),
+		exitc:    exitc,
 	}
 }
 
@@ -69,22 +150,23 @@ func (re *Resync) Start() error {
 
 	// add each cron sync job
 	for name, sync := range re.config.Syncs {
+		// syncs with dependencies are run by the main loop instead of cron
+		if sync.Schedule == nil {
+			log.Infof("Sync Scheduled %s: after %s", name, strings.Join(sync.DependsOn, ", "))
+			continue
+		}
+
 		schedule := StringValue(sync.Schedule)
 
 		// make sure variables used in different goroutine (AddFunc) aren't shadowed
 		err := func(name, schedule string) error {
-			_, err := re.crontab.AddFunc(schedule, func() {
-				// add recovery here so entire program doesn't crash on panic
-				defer func() {
-					if r := recover(); r != nil {
-						log.Errorf("Panic running job %s\n%s", name, debug.Stack())
-					}
-				}()
-
-				if err := re.sync(name); err != nil {
-					log.Errorf("Error running job %s: %v", name, err)
+			id, err := re.crontab.AddFunc(schedule, func() {
+				re.setLastScheduled(name, time.Now())
+				if re.delay(name) {
+					re.job(name)
 				}
 			})
+			re.entries[name] = id
 			return err
 		}(name, schedule)
 		if err != nil {
@@ -116,12 +198,85 @@ func (re *Resync) Start() error {
 	}
 
 	re.running = true
+	re.started = time.Now()
+	re.exitc = make(chan struct{})
 	re.crontab.Start()
 	go re.loop()
 
+	// run each sync at most once even if it has both catch_up and run_on_start set
+	for name, sync := range re.config.Syncs {
+		if re.catchUp(name) || BoolValue(sync.RunOnStart) {
+			go re.job(name)
+		}
+	}
+
 	return nil
 }
 
+// catchUp returns true if the sync with name has catch_up set and its cron job should have fired while resync was
+// stopped. Syncs that have never had a time stored are measured from when resync started.
+func (re *Resync) catchUp(name string) bool {
+	id, ok := re.entries[name]
+	if !ok {
+		return false
+	}
+
+	last, err := re.db.LastScheduled(name)
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+
+	if last.IsZero() {
+		re.setLastScheduled(name, re.started)
+		return false
+	}
+
+	missed := re.crontab.Entry(id).Schedule.Next(last)
+	if !BoolValue(re.config.Syncs[name].CatchUp) || missed.After(re.started) {
+		return false
+	}
+
+	log.Infof("Catching up on %s missed at %s", name, missed.Format(StringValue(re.config.TimeFormat)))
+	re.setLastScheduled(name, re.started)
+	return true
+}
+
+// delay waits for a random amount of time up to the jitter of the sync with name. It returns false if resync
+// stopped while waiting.
+func (re *Resync) delay(name string) bool {
+	jitter := re.config.GetJitter(name)
+	if jitter <= 0 {
+		return true
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(jitter)))
+	if err != nil {
+		log.Errorf("Failed to pick a random delay for %s: %v", name, err)
+		return true
+	}
+
+	wait := time.Duration(n.Int64())
+	log.Infof("Delaying %s by %s", name, wait)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-re.exitc:
+		return false
+	}
+}
+
+// setLastScheduled stores t as the last time the cron job for the sync with name fired.
+func (re *Resync) setLastScheduled(name string, t time.Time) {
+	if err := re.db.SetLastScheduled(name, t); err != nil {
+		log.Errorf("Failed to write the last scheduled time for %s: %v", name, err)
+	}
+}
+
 // Stop stops running cron jobs, closes the db, and kills all running sync jobs.
 func (re *Resync) Stop() {
 	if re.stopping || !re.running {
@@ -135,29 +290,243 @@ func (re *Resync) Stop() {
 	re.running = false
 }
 
+// Run starts the sync with name in the background the same way its cron job would. It returns false if the
+// sync was skipped because it's already running and its overlap policy is skip.
+func (re *Resync) Run(name string) (bool, error) {
+	rc, err := re.acquire(name, false)
+	if err != nil {
+		return false, err
+	}
+
+	if rc == nil {
+		return false, nil
+	}
+
+	go func() {
+		// add recovery here so entire program doesn't crash on panic
+		defer func() {
+			if r := recover(); r != nil {
+				log.Errorf("Panic running job %s\n%s", name, debug.Stack())
+			}
+		}()
+
+		if err := re.run(rc); err != nil {
+			log.Errorf("Error running job %s: %v", name, err)
+		}
+	}()
+
+	return true, nil
+}
+
+// Cancel cancels the running sync with name. The rest of the syncs are left running. It returns false if the sync
+// wasn't running.
+func (re *Resync) Cancel(name string) (bool, error) {
+	return re.request(re.cancelc, name)
+}
+
+// Pause stops the cron job for the sync with name from running the sync until Resume is called. Running the sync
+// with Run still works while it's paused. It returns false if the sync was already paused.
+func (re *Resync) Pause(name string) (bool, error) {
+	return re.request(re.pausec, name)
+}
+
+// Resume lets the cron job for the paused sync with name run the sync again. It returns false if the sync wasn't
+// paused.
+func (re *Resync) Resume(name string) (bool, error) {
+	return re.request(re.resumec, name)
+}
+
+// request sends a syncRequest for name on c and returns the main loop's reply.
+func (re *Resync) request(c chan *syncRequest, name string) (bool, error) {
+	if _, err := re.config.GetSync(name); err != nil {
+		return false, err
+	}
+
+	req := &syncRequest{
+		name:   name,
+		replyc: make(chan bool),
+	}
+
+	select {
+	case c <- req:
+	case <-re.exitc:
+		return false, ErrNotRunning
+	}
+
+	return <-req.replyc, nil
+}
+
+// Subscribe returns a channel that receives sync lifecycle events and a function that must be called to
+// unsubscribe. Events are dropped for subscribers that don't keep up.
+func (re *Resync) Subscribe() (<-chan Event, func()) {
+	return re.events.subscribe()
+}
+
+// Running returns the names of all of the syncs that are currently running.
+func (re *Resync) Running() ([]string, error) {
+	states, err := re.states()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for name, state := range states {
+		if state.Running {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// states returns the running state and counters of every sync that the main loop knows about.
+func (re *Resync) states() (map[string]SyncStatus, error) {
+	replyc := make(chan map[string]SyncStatus)
+
+	select {
+	case re.statec <- replyc:
+	case <-re.exitc:
+		return nil, ErrNotRunning
+	}
+
+	return <-replyc, nil
+}
+
 func (re *Resync) loop() {
+	defer close(re.exitc)
+
+	ticker := time.NewTicker(windowCheckInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case sync := <-re.startc:
-			if _, ok := re.syncs[sync.name]; ok {
-				sync.runningc <- true
-			} else {
-				re.syncs[sync.name] = sync
-				sync.runningc <- false
+			var err error
+			running, ok := re.syncs[sync.name]
+			switch {
+			case sync.scheduled && re.paused[sync.name]:
+				err = errPaused
+			case sync.scheduled && !re.config.Allowed(sync.name, time.Now()):
+				err = errOutsideWindow
+			case !ok:
+				re.admit(sync)
+			case running.next != nil:
+				// another run is already waiting for the running sync to finish
+				err = errAlreadyRunning
+			case StringValue(re.config.Syncs[sync.name].Overlap) == OverlapQueue:
+				log.Infof("Queueing rsync %s until the running rsync finishes", sync.name)
+				running.next = sync
+				re.events.publish(Event{
+					Type: EventQueued,
+					Name: sync.name,
+					Stat: NewStat(sync.name, StringValue(re.config.TimeFormat)),
+				})
+			case StringValue(re.config.Syncs[sync.name].Overlap) == OverlapReplace:
+				log.Infof("Replacing running rsync: %s", sync.name)
+				running.next = sync
+				running.stop(EndCancelled)
+			default:
+				err = errAlreadyRunning
+			}
+
+			if err == errAlreadyRunning {
+				re.counter(sync.name).Skipped++
+			}
+			sync.runningc <- err
+		case req := <-re.cancelc:
+			sync, ok := re.syncs[req.name]
+			if ok {
+				log.Infof("Cancelling running rsync: %s", req.name)
+				sync.stop(EndCancelled)
+
+				// the waiting run is cancelled by the main loop once the running sync finishes
+				if sync.next != nil {
+					sync.next.reason.Store(EndCancelled)
+				}
 			}
+			req.replyc <- ok
+		case req := <-re.pausec:
+			log.Infof("Pausing rsync: %s", req.name)
+			req.replyc <- !re.paused[req.name]
+			re.paused[req.name] = true
+		case req := <-re.resumec:
+			log.Infof("Resuming rsync: %s", req.name)
+			req.replyc <- re.paused[req.name]
+			delete(re.paused, req.name)
+		case req := <-re.rotatedc:
+			sync, ok := re.syncs[req.name]
+			req.replyc <- ok && atomic.LoadInt32(&sync.rotated) == 1
+		case replyc := <-re.statec:
+			states := make(map[string]SyncStatus)
+			for name, counters := range re.counters {
+				states[name] = *counters
+			}
+			for name, sync := range re.syncs {
+				state := states[name]
+				state.Name = name
+				state.Running = !sync.queued
+				state.Queued = sync.queued || sync.next != nil
+				if progress, ok := sync.progress.Load().(Progress); ok {
+					state.Progress = &progress
+				}
+				states[name] = state
+			}
+			for name := range re.paused {
+				state := states[name]
+				state.Name = name
+				state.Paused = true
+				states[name] = state
+			}
+			replyc <- states
 		case sync := <-re.endc:
 			delete(re.syncs, sync.name)
+			if next := sync.next; next != nil {
+				reason := next.stopped()
+				if re.stopping {
+					reason = EndShutdown
+				} else if reason == "" && next.scheduled && !re.config.Allowed(next.name, time.Now()) {
+					reason = EndCancelled
+				}
+
+				if reason != "" {
+					// keep track of the cancelled run until it finishes without starting
+					next.queued = true
+					next.stop(reason)
+					re.syncs[next.name] = next
+				} else {
+					re.admit(next)
+				}
+			}
+			re.dequeue(sync)
+
+			if sync.stat != nil {
+				counters := re.counter(sync.name)
+				counters.Runs++
+				switch sync.stat.Status {
+				case StatusFailed:
+					counters.Failures++
+				case StatusTimedOut:
+					counters.Failures++
+					counters.TimedOut++
+				}
+
+				if !re.stopping {
+					re.triggerDependents(sync.name, *sync.stat)
+				}
+			}
 
 			if re.stopping && len(re.syncs) == 0 {
 				re.donec <- struct{}{}
 				return
 			}
+		case now := <-ticker.C:
+			re.closeWindows(now)
 		case <-re.stopc:
 			re.stopping = true
 			re.crontab.Stop()
 			for name, sync := range re.syncs {
 				log.Infof("Cancelling running rsync: %s", name)
-				sync.cancel()
+				sync.stop(EndShutdown)
 			}
 
 			if len(re.syncs) == 0 {
@@ -168,59 +537,364 @@ func (re *Resync) loop() {
 	}
 }
 
+// counter returns the counters for the sync with name. It's only called by the main loop.
+func (re *Resync) counter(name string) *SyncStatus {
+	counters, ok := re.counters[name]
+	if !ok {
+		counters = &SyncStatus{Name: name}
+		re.counters[name] = counters
+	}
+	return counters
+}
+
+// admit starts the sync if it's under max_concurrent and max_concurrent_per_destination and queues it otherwise. It's
+// only called by the main loop.
+func (re *Resync) admit(sync *runningSync) {
+	re.syncs[sync.name] = sync
+	if re.canStart(sync.name) {
+		close(sync.startedc)
+		return
+	}
+
+	log.Infof("Queueing rsync: %s", sync.name)
+	sync.queued = true
+	re.queue = append(re.queue, sync)
+	re.events.publish(Event{
+		Type: EventQueued,
+		Name: sync.name,
+		Stat: NewStat(sync.name, StringValue(re.config.TimeFormat)),
+	})
+}
+
+// canStart returns true if the sync with name can start without going over max_concurrent or
+// max_concurrent_per_destination. It's only called by the main loop.
+func (re *Resync) canStart(name string) bool {
+	maxConcurrent := IntValue(re.config.MaxConcurrent)
+	maxPerDestination := IntValue(re.config.MaxConcurrentPerDestination)
+	host := re.config.Syncs[name].DestinationHost()
+
+	running, sameDestination := 0, 0
+	for other, rc := range re.syncs {
+		if rc.queued || other == name {
+			continue
+		}
+
+		running++
+		// local destinations aren't limited per destination
+		if host != "" && re.config.Syncs[other].DestinationHost() == host {
+			sameDestination++
+		}
+	}
+
+	return (maxConcurrent < 1 || running < maxConcurrent) && (maxPerDestination < 1 || sameDestination < maxPerDestination)
+}
+
+// dequeue removes the finished sync from the queue if it was cancelled while queued and then starts the queued
+// syncs that can run. Syncs with a higher priority start first. It's only called by the main loop.
+func (re *Resync) dequeue(finished *runningSync) {
+	for i, rc := range re.queue {
+		if rc == finished {
+			re.queue = append(re.queue[:i], re.queue[i+1:]...)
+			break
+		}
+	}
+
+	if re.stopping {
+		return
+	}
+
+	for {
+		next := -1
+		for i, rc := range re.queue {
+			// cancelled syncs and syncs outside of their windows are left for their run to clean up
+			if rc.stopped() != "" || (rc.scheduled && !re.config.Allowed(rc.name, time.Now())) || !re.canStart(rc.name) {
+				continue
+			}
+
+			if next < 0 || IntValue(re.config.Syncs[rc.name].Priority) > IntValue(re.config.Syncs[re.queue[next].name].Priority) {
+				next = i
+			}
+		}
+
+		if next < 0 {
+			return
+		}
+
+		rc := re.queue[next]
+		re.queue = append(re.queue[:next], re.queue[next+1:]...)
+		rc.queued = false
+		close(rc.startedc)
+	}
+}
+
+// closeWindows cancels the syncs started by cron or by their dependencies that aren't allowed to run at now. Queued
+// syncs are always cancelled because they haven't started yet. Running syncs are only cancelled if their
+// window_close is cancel. It's only called by the main loop.
+func (re *Resync) closeWindows(now time.Time) {
+	if re.stopping {
+		return
+	}
+
+	for name, sync := range re.syncs {
+		if !sync.scheduled || sync.stopped() != "" || re.config.Allowed(name, now) {
+			continue
+		}
+
+		if !sync.queued && re.config.GetWindowClose(name) != WindowCloseCancel {
+			continue
+		}
+
+		log.Infof("Cancelling rsync %s because its window closed", name)
+		sync.stop(EndCancelled)
+	}
+}
+
+// triggerDependents records the finished run of the sync with name for the syncs that depend on it. Dependents
+// are started once every sync they depend on has succeeded since they were last started. A failed run has to be
+// followed by a successful one before it counts. It's only called by the main loop.
+func (re *Resync) triggerDependents(name string, stat Stat) {
+	// cancelled runs don't count as a success or a failure
+	if stat.Status == StatusCancelled {
+		return
+	}
+
+	for _, dependent := range re.config.Dependents(name) {
+		done, ok := re.depsDone[dependent]
+		if !ok {
+			done = make(map[string]bool)
+			re.depsDone[dependent] = done
+		}
+
+		if !stat.Success() {
+			delete(done, name)
+			continue
+		}
+		done[name] = true
+
+		ready := true
+		for _, dep := range re.config.Syncs[dependent].DependsOn {
+			ready = ready && done[dep]
+		}
+
+		if !ready {
+			continue
+		}
+
+		// every dependency succeeded so start the next cycle
+		delete(re.depsDone, dependent)
+		log.Infof("Dependencies of %s succeeded", dependent)
+		go re.job(dependent)
+	}
+}
+
+// job runs the sync with name the way a cron job does.
+func (re *Resync) job(name string) {
+	// add recovery here so entire program doesn't crash on panic
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Panic running job %s\n%s", name, debug.Stack())
+		}
+	}()
+
+	if err := re.sync(name); err != nil {
+		log.Errorf("Error running job %s: %v", name, err)
+	}
+}
+
 func (re *Resync) sync(name string) error {
-	sync, err := re.config.GetSync(name)
-	if err != nil {
+	rc, err := re.acquire(name, true)
+	if err != nil || rc == nil {
 		return err
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	return re.run(rc)
+}
 
-	if timeLimit, err := re.config.GetTimeLimit(name); err == nil {
-		var timeoutCancel context.CancelFunc
-		ctx, timeoutCancel = context.WithTimeout(context.Background(), timeLimit)
-		defer timeoutCancel()
+// acquire registers the sync with the main loop. Scheduled is true when the cron job is running the sync. If the
+// sync is skipped a nil runningSync is returned.
+func (re *Resync) acquire(name string, scheduled bool) (*runningSync, error) {
+	if _, err := re.config.GetSync(name); err != nil {
+		return nil, err
 	}
 
-	cmd := exec.CommandContext(ctx, StringValue(re.config.RsyncPath), sync.Args()...)
+	// the time limit is added by run once the sync leaves the queue
+	ctx, cancel := context.WithCancel(context.Background())
 
 	// inform main loop that we're running a sync
 	rc := &runningSync{
-		name:     name,
-		cancel:   cancel,
-		runningc: make(chan bool),
+		name:      name,
+		scheduled: scheduled,
+		ctx:       ctx,
+		cancel:    cancel,
+		runningc:  make(chan error),
+		startedc:  make(chan struct{}),
+		queuedAt:  time.Now(),
+	}
+
+	select {
+	case re.startc <- rc:
+	case <-re.exitc:
+		cancel()
+		return nil, ErrNotRunning
+	}
+
+	// check runningc to see if the sync is skipped
+	if err := <-rc.runningc; err != nil {
+		cancel()
+		log.Infof("Skipping rsync %s because %v", name, err)
+		re.skip(name, err)
+		return nil, nil
+	}
+
+	return rc, nil
+}
+
+// skip publishes the skipped event for the sync with name. Runs skipped because the sync is already running are also
+// stored and emailed if on_skip is set. Runs skipped because the sync is paused aren't.
+func (re *Resync) skip(name string, err error) {
+	stat := NewStat(name, StringValue(re.config.TimeFormat)).Skip(err)
+	re.events.publish(Event{
+		Type: EventSkipped,
+		Name: name,
+		Stat: stat,
+	})
+
+	if !errors.Is(err, errAlreadyRunning) {
+		return
 	}
-	re.startc <- rc
 
-	// check runningc to see if the sync is already running
-	if running := <-rc.runningc; running {
-		log.Infof("Skipping rsync %s because it's already running", name)
+	if re.config.Email != nil && BoolValue(re.config.Email.OnSkip) {
+		if err := re.notifier.Notify(stat); err != nil {
+			log.Error(err)
+		}
+	}
+
+	if IntValue(re.config.Retention) > 0 {
+		if err := re.db.Insert(stat); err != nil {
+			log.Errorf("Failed to write stats for %s: %v", name, err)
+		}
+	}
+}
+
+// run runs the rsync command for a sync that was registered by acquire.
+func (re *Resync) run(rc *runningSync) error {
+	// inform main loop that the sync is complete
+	defer func() {
+		re.endc <- rc
+	}()
+	defer rc.cancel()
+
+	name := rc.name
+	sync, err := re.config.GetSync(name)
+	if err != nil {
+		return err
+	}
+
+	// wait for the main loop to start the sync if it's queued
+	select {
+	case <-rc.startedc:
+	case <-rc.ctx.Done():
+	}
+
+	// the sync never started if it was cancelled before it left the queue
+	select {
+	case <-rc.startedc:
+	default:
+		log.Infof("Cancelled %s while queued", name)
+		stat := NewStat(name, StringValue(re.config.TimeFormat)).Finish(rc.ctx.Err())
+		stat.Status = StatusCancelled
+		stat.EndReason = rc.stopped()
+		re.events.publish(newFinishedEvent(stat))
 		return nil
 	}
+	queued := time.Since(rc.queuedAt)
+
+	if timeLimit, err := re.config.GetTimeLimit(name); err == nil {
+		ctx, cancel := context.WithTimeout(rc.ctx, timeLimit)
+		defer cancel()
+		rc.ctx = ctx
+	}
 
 	// rotate logs
 	stdoutLog, stderrLog, err := re.logger.Rotate(name)
 	if err != nil {
 		return err
 	}
+	atomic.StoreInt32(&rc.rotated, 1)
 
-	cmd.Stdout = stdoutLog
-	cmd.Stderr = stderrLog
+	var stdout, stderr io.Writer
+	if stdoutLog != nil {
+		defer stdoutLog.Close()
+		stdout = stdoutLog
+	}
 
-	log.Infof("Running %s: %s %s", name, StringValue(re.config.RsyncPath), strings.Join(sync.Args(), " "))
+	if stderrLog != nil {
+		defer stderrLog.Close()
+		stderr = stderrLog
+	}
 
 	stat := NewStat(name, StringValue(re.config.TimeFormat))
-	err = cmd.Run()
+	stat.Queued = queued
+	re.events.publish(Event{
+		Type: EventStarted,
+		Name: name,
+		Stat: stat,
+	})
+
+	manifest := NewManifest(stat)
+
+	if sync.PreCommand != nil {
+		err = re.hook(rc.ctx, hookPre, StringValue(sync.PreCommand), sync, stat, stdout, stderr)
+	}
+
+	preFailed := err != nil
+	if !preFailed {
+		err = re.transfer(rc, sync, &stat, &manifest, stdout, stderr)
+	}
+
 	stat = stat.Finish(err)
+	stat.EndReason = EndCompleted
+	if reason := rc.stopped(); !stat.Success() && reason != "" {
+		stat.Status = StatusCancelled
+		stat.EndReason = reason
+	} else if !stat.Success() && errors.Is(rc.ctx.Err(), context.DeadlineExceeded) {
+		stat.Status = StatusTimedOut
+		stat.EndReason = EndTimedOut
+	}
 
-	if stat.Success {
+	if preFailed {
+		// the hook's exit status is kept in Error since ExitCode is only for rsync
+		stat.ExitCode = 0
+		stat.ExitDescription = hookPre + " failed"
+		if stat.Status == StatusFailed {
+			stat.EndReason = EndPreCommandFailed
+		}
+	} else if stat.Status == StatusFailed && sync.IsWarning(stat.ExitCode) {
+		stat.Status = StatusWarning
+		err = nil
+	}
+	rc.stat = &stat
+
+	switch {
+	case stat.Status == StatusWarning:
+		log.Warnf("Finished %s with warning after %s: %s", name, stat.Duration, stat.ExitDescription)
+	case stat.Status == StatusSuccess:
 		log.Infof("Finished %s after %s", name, stat.Duration)
-	} else {
-		log.Errorf("Error %s: after %s: %s", name, stat.Duration, err)
+	case stat.EndReason == EndShutdown:
+		log.Infof("Stopped %s after %s because resync is shutting down", name, stat.Duration)
+	case stat.Status == StatusCancelled:
+		log.Infof("Cancelled %s after %s", name, stat.Duration)
+	case stat.Status == StatusTimedOut:
+		log.Errorf("Time limit exceeded %s: after %s: %s", name, stat.Duration, err)
+	default:
+		log.Errorf("Error %s: after %s: %s (%s)", name, stat.Duration, err, stat.ExitDescription)
 	}
 
-	if err != nil && re.config.Email != nil && BoolValue(re.config.Email.OnFailure) {
+	re.postHooks(sync, stat, stdout, stderr)
+
+	re.events.publish(newFinishedEvent(stat))
+
+	if err != nil && stat.Status != StatusCancelled && re.config.Email != nil && BoolValue(re.config.Email.OnFailure) {
 		if err := re.notifier.Notify(stat); err != nil {
 			log.Error(err)
 		}
@@ -230,14 +904,127 @@ func (re *Resync) sync(name string) error {
 		if err := re.db.Insert(stat); err != nil {
 			log.Errorf("Failed to write stats for %s: %v", name, err)
 		}
+
+		if BoolValue(sync.Manifest) {
+			if err := re.db.InsertManifest(manifest); err != nil {
+				log.Errorf("Failed to write manifest for %s: %v", name, err)
+			}
+		}
 	}
 
-	// inform main loop that the sync is complete
-	re.endc <- rc
+	return err
+}
+
+// transfer runs rsync for the sync and retries failed attempts. It adds the attempts and transfer stats to stat and
+// the changed paths to manifest. If bandwidth_restart is set then rsync is restarted whenever the sync's bandwidth
+// limit changes.
+func (re *Resync) transfer(rc *runningSync, sync *Sync, stat *Stat, manifest *Manifest, stdout io.Writer, stderr io.Writer) error {
+	var err error
+
+	retries := re.config.GetRetries(rc.name)
+	backoff := re.config.GetRetryBackoff(rc.name)
+	parseStats := hasStatsArg(sync.Args())
+	parseManifest := BoolValue(sync.Manifest)
+	parseProgress := hasProgressArg(sync.Args())
+
+	for i := 0; ; {
+		now := bandwidthClock()
+		args := sync.ArgsAt(now)
+		log.Infof("Running %s: %s %s", rc.name, StringValue(re.config.RsyncPath), strings.Join(args, " "))
+
+		ctx, restarted := rc.ctx, func() bool { return false }
+		if BoolValue(sync.BandwidthRestart) {
+			limit, _ := sync.BandwidthAt(now)
+			ctx, restarted = watchBandwidth(rc.ctx, sync, limit)
+		}
+
+		attempt := NewAttempt(StringValue(re.config.TimeFormat))
+		if parseStats || parseManifest || parseProgress {
+			// only keep the stats from the last attempt but keep the changes from every attempt
+			transfer := TransferStats{}
+			lw := newLineWriter(func(line string) {
+				if parseStats {
+					transfer.parseLine(line)
+				}
+
+				if parseManifest {
+					if entry, ok := parseItemizedLine(line); ok {
+						manifest.Entries = append(manifest.Entries, entry)
+					}
+				}
+
+				if parseProgress {
+					rc.updateProgress(line)
+				}
+			})
+			if parseProgress {
+				lw.partial = rc.updateProgress
+			}
+
+			err = re.rsync(ctx, rc.name, args, multiWriter(stdout, lw), stderr)
+			lw.Flush()
+			stat.TransferStats = transfer
+		} else {
+			err = re.rsync(ctx, rc.name, args, stdout, stderr)
+		}
+		stat.Attempts = append(stat.Attempts, attempt.Finish(err))
+
+		// restarts for a new bandwidth limit don't count as retries
+		if restarted() && err != nil && rc.ctx.Err() == nil {
+			log.Infof("Restarting %s because its bandwidth limit changed", rc.name)
+			continue
+		}
+
+		// don't retry after success or a warning or after the sync was cancelled or timed out
+		if err == nil || sync.IsWarning(exitCode(err)) || i >= retries || rc.ctx.Err() != nil {
+			break
+		}
+
+		wait := retryWait(backoff, i)
+		log.Warnf("Retrying %s in %s after attempt %d of %d failed: %s", rc.name, wait, i+1, retries+1, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-rc.ctx.Done():
+			timer.Stop()
+		}
+
+		if rc.ctx.Err() != nil {
+			break
+		}
+		i++
+	}
 
 	return err
 }
 
+// maxRetryBackoff is the longest wait between retries no matter how many retries have failed.
+const maxRetryBackoff = time.Hour
+
+// retryWait returns how long to wait before retrying after attempt i failed. The wait starts at backoff and doubles
+// after each retry up to maxRetryBackoff.
+func retryWait(backoff time.Duration, i int) time.Duration {
+	wait := backoff
+	for ; i > 0 && wait > 0 && wait < maxRetryBackoff; i-- {
+		wait *= 2
+	}
+
+	if wait > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return wait
+}
+
+// rsync runs the rsync command for the sync with name once with args. If ctx is done before the command exits then it's
+// sent SIGTERM and killed after the sync's grace period.
+func (re *Resync) rsync(ctx context.Context, name string, args []string, stdout io.Writer, stderr io.Writer) error {
+	cmd := exec.Command(StringValue(re.config.RsyncPath), args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return runCommand(ctx, cmd, re.config.GetGracePeriod(name))
+}
+
 // Dump prints all of the stats to STDOUT.
 func (re *Resync) Dump() error {
 	if IntValue(re.config.Retention) < 1 {
@@ -249,12 +1036,18 @@ func (re *Resync) Dump() error {
 		return err
 	}
 
-	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	return WriteStats(os.Stdout, stats)
+}
+
+// WriteStats writes stats to w as a table for each sync.
+func WriteStats(w io.Writer, stats map[string][]Stat) error {
+	writer := tabwriter.NewWriter(w, 0, 8, 1, '\t', tabwriter.AlignRight)
 
 	for _, stats := range stats {
-		fmt.Fprintln(writer, "NAME\tSUCCESS\tSTART\tEND\tDURATION")
+		fmt.Fprintln(writer, "NAME\tSTATUS\tEXIT\tEND REASON\tSTART\tEND\tDURATION\tQUEUED\tFILES\tTRANSFERRED\tSIZE\tTRANSFERRED SIZE\tLITERAL\tMATCHED\tSPEEDUP")
 		for _, stat := range stats {
-			fmt.Fprintf(writer, "%s\t%t\t%s\t%s\t%s\n", stat.Name, stat.Success, stat.Start, stat.End, stat.Duration)
+			fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%.2f\n", stat.Name, stat.Status, stat.ExitCode, stat.EndReason, stat.Start, stat.End, stat.Duration, stat.Queued,
+				stat.FilesConsidered, stat.FilesTransferred, stat.TotalSize, stat.TransferredSize, stat.LiteralData, stat.MatchedData, stat.Speedup)
 		}
 		fmt.Fprintln(writer)
 	}
@@ -1,8 +1,12 @@
 package resync
 
 import (
+	"context"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -100,3 +104,1198 @@ func TestStart(t *testing.T) {
 	err = re.Dump()
 	assert.Nil(t, err)
 }
+
+func TestRun(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		Retention: Int(0),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"./testdata/a/"},
+				RsyncDestination: String(dir),
+				Schedule:         String("0 0 1 1 *"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	_, err = re.Run("test")
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	err = re.Start()
+	assert.Nil(t, err)
+
+	_, err = re.Run("foo")
+	assert.Error(t, err)
+
+	started, err := re.Run("test")
+	assert.Nil(t, err)
+	assert.True(t, started)
+
+	time.Sleep(time.Second)
+	re.Stop()
+
+	b, err := os.ReadFile(filepath.Join(dir, "test"))
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte("Hello World"))
+}
+
+func TestCancel(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		RsyncPath: String("sleep"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("5"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	_, err = re.Cancel("test")
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	_, err = re.Cancel("foo")
+	assert.Error(t, err)
+
+	cancelled, err := re.Cancel("test")
+	assert.Nil(t, err)
+	assert.False(t, cancelled)
+
+	started, err := re.Run("test")
+	assert.Nil(t, err)
+	assert.True(t, started)
+
+	cancelled, err = re.Cancel("test")
+	assert.Nil(t, err)
+	assert.True(t, cancelled)
+
+	// wait for the cancelled sync to be recorded
+	time.Sleep(500 * time.Millisecond)
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["test"], 1)
+	assert.False(t, stats["test"][0].Success())
+	assert.Equal(t, StatusCancelled, stats["test"][0].Status)
+}
+
+func TestPause(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		RsyncPath:    String("sleep"),
+		LogPath:      String(dir),
+		LibPath:      String(dir),
+		SecondsField: Bool(true),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("0"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("* * * * * *"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	_, err = re.Pause("test")
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	_, err = re.Pause("foo")
+	assert.Error(t, err)
+
+	paused, err := re.Pause("test")
+	assert.Nil(t, err)
+	assert.True(t, paused)
+
+	paused, err = re.Pause("test")
+	assert.Nil(t, err)
+	assert.False(t, paused)
+
+	// the cron job is skipped while paused
+	time.Sleep(1500 * time.Millisecond)
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["test"], 0)
+
+	// running manually still works while paused
+	started, err := re.Run("test")
+	assert.Nil(t, err)
+	assert.True(t, started)
+
+	time.Sleep(100 * time.Millisecond)
+
+	stats, err = db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["test"], 1)
+
+	resumed, err := re.Resume("test")
+	assert.Nil(t, err)
+	assert.True(t, resumed)
+
+	resumed, err = re.Resume("test")
+	assert.Nil(t, err)
+	assert.False(t, resumed)
+
+	time.Sleep(1500 * time.Millisecond)
+
+	stats, err = db.List()
+	assert.Nil(t, err)
+	assert.Greater(t, len(stats["test"]), 1)
+}
+
+func TestRetry(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		RsyncPath:    String("false"),
+		LogPath:      String(dir),
+		LibPath:      String(dir),
+		Retries:      Int(2),
+		RetryBackoff: String("100ms"),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("0"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+			"limited": {
+				RsyncArgs:        String("0"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+				TimeLimit:        String("500ms"),
+				RetryBackoff:     String("1s"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	started, err := re.Run("test")
+	assert.Nil(t, err)
+	assert.True(t, started)
+
+	started, err = re.Run("limited")
+	assert.Nil(t, err)
+	assert.True(t, started)
+
+	// wait for 100ms and 200ms of backoff and the time limit
+	time.Sleep(time.Second)
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+
+	assert.Len(t, stats["test"], 1)
+	assert.False(t, stats["test"][0].Success())
+	assert.Len(t, stats["test"][0].Attempts, 3)
+	for _, attempt := range stats["test"][0].Attempts {
+		assert.NotEmpty(t, attempt.Error)
+	}
+
+	// the time limit ends the backoff before the first retry
+	assert.Len(t, stats["limited"], 1)
+	assert.False(t, stats["limited"][0].Success())
+	assert.Equal(t, StatusTimedOut, stats["limited"][0].Status)
+	assert.Len(t, stats["limited"][0].Attempts, 1)
+}
+
+func TestRetryWait(t *testing.T) {
+	assert.Equal(t, 30*time.Second, retryWait(30*time.Second, 0))
+	assert.Equal(t, time.Minute, retryWait(30*time.Second, 1))
+	assert.Equal(t, 8*time.Minute, retryWait(30*time.Second, 4))
+	assert.Equal(t, maxRetryBackoff, retryWait(30*time.Second, 10))
+
+	// large retry counts don't overflow
+	assert.Equal(t, maxRetryBackoff, retryWait(30*time.Second, 100))
+	assert.Equal(t, maxRetryBackoff, retryWait(time.Nanosecond, 1000000))
+	assert.Equal(t, maxRetryBackoff, retryWait(2*time.Hour, 3))
+	assert.Equal(t, time.Duration(0), retryWait(0, 1000000))
+}
+
+func TestWarning(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		RsyncPath:    String("sh"),
+		LogPath:      String(dir),
+		LibPath:      String(dir),
+		Retries:      Int(2),
+		RetryBackoff: String("100ms"),
+		Syncs: map[string]*Sync{
+			"vanished": {
+				RsyncArgs:        String("-c"),
+				RsyncSource:      []string{"exit 24"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+				WarningExitCodes: []int{24},
+			},
+			"partial": {
+				RsyncArgs:        String("-c"),
+				RsyncSource:      []string{"exit 23"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+				Retries:          Int(0),
+				WarningExitCodes: []int{24},
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	_, err = re.Run("vanished")
+	assert.Nil(t, err)
+	_, err = re.Run("partial")
+	assert.Nil(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+
+	// warnings count as a success and aren't retried
+	assert.Len(t, stats["vanished"], 1)
+	assert.True(t, stats["vanished"][0].Success())
+	assert.Equal(t, StatusWarning, stats["vanished"][0].Status)
+	assert.Equal(t, 24, stats["vanished"][0].ExitCode)
+	assert.Equal(t, "Partial transfer due to vanished source files", stats["vanished"][0].ExitDescription)
+	assert.Len(t, stats["vanished"][0].Attempts, 1)
+
+	assert.Len(t, stats["partial"], 1)
+	assert.False(t, stats["partial"][0].Success())
+	assert.NotEqual(t, StatusWarning, stats["partial"][0].Status)
+	assert.Equal(t, 23, stats["partial"][0].ExitCode)
+	assert.Equal(t, "exit status 23", stats["partial"][0].Error)
+
+	err = re.Health("vanished")
+	assert.Nil(t, err)
+}
+
+func TestTransferStatsRun(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		RsyncPath: String("./testdata/stats.sh"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a --stats"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["test"], 1)
+	assert.True(t, stats["test"][0].Success())
+	assert.Equal(t, TransferStats{
+		FilesConsidered:  2,
+		FilesTransferred: 1,
+		TotalSize:        2048,
+		TransferredSize:  1024,
+		LiteralData:      1000,
+		MatchedData:      24,
+		Speedup:          1.66,
+	}, stats["test"][0].TransferStats)
+
+	// the output is still written to the log
+	stdout, err := logger.Stdout("test")
+	assert.Nil(t, err)
+	defer stdout.Close()
+
+	b, err := io.ReadAll(stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "speedup is 1.66")
+}
+
+func TestDependsOnRun(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		RsyncPath: String("sh"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"local": {
+				RsyncArgs:        String("-c"),
+				RsyncSource:      []string{"exit 0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+			"staging": {
+				RsyncArgs:        String("-c"),
+				RsyncSource:      []string{"exit 0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+			"offsite": {
+				RsyncArgs:        String("-c"),
+				RsyncSource:      []string{"exit 0"},
+				RsyncDestination: String("0"),
+				DependsOn:        []string{"local", "staging"},
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	runs := func(name string) int {
+		status, err := re.Status(name)
+		assert.Nil(t, err)
+		return status.Runs
+	}
+
+	_, err = re.Run("local")
+	assert.Nil(t, err)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, 0, runs("offsite"))
+
+	// a failed run has to be followed by a successful one
+	config.Syncs["staging"].RsyncSource = []string{"exit 1"}
+	_, err = re.Run("staging")
+	assert.Nil(t, err)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, 0, runs("offsite"))
+
+	config.Syncs["staging"].RsyncSource = []string{"exit 0"}
+	_, err = re.Run("staging")
+	assert.Nil(t, err)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, 1, runs("offsite"))
+
+	// the next cycle needs both dependencies to succeed again
+	_, err = re.Run("staging")
+	assert.Nil(t, err)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, 1, runs("offsite"))
+
+	_, err = re.Run("local")
+	assert.Nil(t, err)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, 2, runs("offsite"))
+
+	status, err := re.Status("offsite")
+	assert.Nil(t, err)
+	assert.Equal(t, "", status.Schedule)
+	assert.Equal(t, []string{"local", "staging"}, status.DependsOn)
+	assert.True(t, status.Next.IsZero())
+}
+
+func TestQueue(t *testing.T) {
+	re, cleanup := newTestResync(t, &Config{
+		RsyncPath:     String("sh"),
+		MaxConcurrent: Int(1),
+		Syncs: map[string]*Sync{
+			"first":  newSleepSync("0.3", "0", 0),
+			"low":    newSleepSync("0.3", "0", 0),
+			"high":   newSleepSync("0.3", "0", 10),
+			"cancel": newSleepSync("0.3", "0", 0),
+		},
+	})
+	defer cleanup()
+
+	events, unsubscribe := re.Subscribe()
+	defer unsubscribe()
+
+	for _, name := range []string{"first", "low", "high", "cancel"} {
+		started, err := re.Run(name)
+		assert.Nil(t, err)
+		assert.True(t, started)
+	}
+
+	// queued syncs are skipped if they're run again
+	started, err := re.Run("low")
+	assert.Nil(t, err)
+	assert.False(t, started)
+
+	status, err := re.Status("low")
+	assert.Nil(t, err)
+	assert.False(t, status.Running)
+	assert.True(t, status.Queued)
+
+	running, err := re.Running()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first"}, running)
+
+	cancelled, err := re.Cancel("cancel")
+	assert.Nil(t, err)
+	assert.True(t, cancelled)
+
+	time.Sleep(1200 * time.Millisecond)
+
+	order := make([]string, 0)
+	for len(events) > 0 {
+		event := <-events
+		if event.Type == EventStarted {
+			order = append(order, event.Name)
+		}
+	}
+
+	// the high priority sync runs before the low priority sync even though it was queued later
+	assert.Equal(t, []string{"first", "high", "low"}, order)
+
+	stats, err := re.db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["cancel"], 0)
+	for _, name := range []string{"first", "high", "low"} {
+		assert.Equal(t, StatusSuccess, stats[name][0].Status, name)
+	}
+	assert.Less(t, stats["first"][0].Queued, 100*time.Millisecond)
+	assert.Greater(t, stats["low"][0].Queued, 500*time.Millisecond)
+	assert.Less(t, stats["low"][0].Duration, 500*time.Millisecond)
+}
+
+func TestQueuePerDestination(t *testing.T) {
+	re, cleanup := newTestResync(t, &Config{
+		RsyncPath:                   String("sh"),
+		MaxConcurrentPerDestination: Int(1),
+		Syncs: map[string]*Sync{
+			"nas1":    newSleepSync("0.3", "nas:/a", 0),
+			"nas2":    newSleepSync("0.3", "nas:/b", 0),
+			"offsite": newSleepSync("0.3", "offsite:/a", 0),
+			"local1":  newSleepSync("0.3", "/mnt/a", 0),
+			"local2":  newSleepSync("0.3", "/mnt/b", 0),
+		},
+	})
+	defer cleanup()
+
+	for _, name := range []string{"nas1", "nas2", "offsite", "local1", "local2"} {
+		_, err := re.Run(name)
+		assert.Nil(t, err)
+	}
+
+	// statuses are sorted by name
+	statuses, err := re.Statuses()
+	assert.Nil(t, err)
+	assert.True(t, statuses[2].Running)
+	assert.True(t, statuses[3].Queued)
+	assert.True(t, statuses[4].Running)
+
+	// local destinations aren't limited
+	assert.True(t, statuses[0].Running)
+	assert.True(t, statuses[1].Running)
+
+	time.Sleep(800 * time.Millisecond)
+
+	statuses, err = re.Statuses()
+	assert.Nil(t, err)
+	for _, status := range statuses {
+		assert.False(t, status.Running)
+		assert.False(t, status.Queued)
+		assert.Equal(t, 1, status.Runs)
+		assert.Equal(t, 0, status.Failures)
+	}
+
+	stats, err := re.db.List()
+	assert.Nil(t, err)
+	for _, name := range []string{"nas1", "nas2", "offsite", "local1", "local2"} {
+		assert.Len(t, stats[name], 1)
+		assert.Equal(t, StatusSuccess, stats[name][0].Status, name)
+	}
+}
+
+// newSleepSync returns a sync that sleeps for seconds when rsync_path is sh. The destination is passed to sh as $0 so
+// it's ignored.
+func newSleepSync(seconds string, dest string, priority int) *Sync {
+	return &Sync{
+		RsyncArgs:        String("-c"),
+		RsyncSource:      []string{"sleep " + seconds},
+		RsyncDestination: String(dest),
+		Schedule:         String("0 0 1 1 *"),
+		Priority:         Int(priority),
+	}
+}
+
+// newTestResync validates config and returns a started Resync. LogPath and LibPath are set to a temporary directory
+// that's removed by the returned cleanup function.
+func newTestResync(t *testing.T, config *Config) (*Resync, func()) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+
+	config.LogPath = String(dir)
+	config.LibPath = String(dir)
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+
+	return re, func() {
+		re.Stop()
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestOverlapRun(t *testing.T) {
+	skip := newSleepSync("0.4", "0", 0)
+	queue := newSleepSync("0.4", "0", 0)
+	queue.Overlap = String(OverlapQueue)
+	replace := newSleepSync("0.4", "0", 0)
+	replace.Overlap = String(OverlapReplace)
+
+	re, cleanup := newTestResync(t, &Config{
+		RsyncPath: String("sh"),
+		Syncs: map[string]*Sync{
+			"skip":    skip,
+			"queue":   queue,
+			"replace": replace,
+		},
+	})
+	defer cleanup()
+
+	for _, name := range []string{"skip", "queue", "replace"} {
+		started, err := re.Run(name)
+		assert.Nil(t, err)
+		assert.True(t, started)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	started, err := re.Run("skip")
+	assert.Nil(t, err)
+	assert.False(t, started)
+
+	// only one run waits for the running sync
+	started, err = re.Run("queue")
+	assert.Nil(t, err)
+	assert.True(t, started)
+	started, err = re.Run("queue")
+	assert.Nil(t, err)
+	assert.False(t, started)
+
+	status, err := re.Status("queue")
+	assert.Nil(t, err)
+	assert.True(t, status.Running)
+	assert.True(t, status.Queued)
+	assert.Equal(t, 1, status.Skipped)
+
+	started, err = re.Run("replace")
+	assert.Nil(t, err)
+	assert.True(t, started)
+
+	time.Sleep(1200 * time.Millisecond)
+
+	stats, err := re.db.List()
+	assert.Nil(t, err)
+
+	// skipped runs are stored
+	assert.Len(t, stats["skip"], 2)
+	assert.Equal(t, StatusSkipped, stats["skip"][0].Status)
+	assert.True(t, stats["skip"][1].Success())
+
+	// the queued run starts after the first run finishes
+	assert.Len(t, stats["queue"], 3)
+	assert.True(t, stats["queue"][0].Success())
+	assert.Equal(t, StatusSkipped, stats["queue"][1].Status)
+	assert.True(t, stats["queue"][2].Success())
+	assert.False(t, stats["queue"][0].StartTime.Before(stats["queue"][2].EndTime))
+	assert.Greater(t, stats["queue"][0].Queued, 200*time.Millisecond)
+
+	// the replaced run is cancelled
+	assert.Len(t, stats["replace"], 2)
+	assert.True(t, stats["replace"][0].Success())
+	assert.Equal(t, StatusCancelled, stats["replace"][1].Status)
+
+	status, err = re.Status("skip")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, status.Skipped)
+	assert.NotEqual(t, StatusSkipped, status.LastStat.Status)
+}
+
+func TestOverlapCancel(t *testing.T) {
+	queue := newSleepSync("0.4", "0", 0)
+	queue.Overlap = String(OverlapQueue)
+
+	re, cleanup := newTestResync(t, &Config{
+		RsyncPath: String("sh"),
+		Syncs: map[string]*Sync{
+			"queue": queue,
+		},
+	})
+	defer cleanup()
+
+	_, err := re.Run("queue")
+	assert.Nil(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = re.Run("queue")
+	assert.Nil(t, err)
+
+	// cancelling the sync also cancels the run waiting for it
+	cancelled, err := re.Cancel("queue")
+	assert.Nil(t, err)
+	assert.True(t, cancelled)
+	time.Sleep(300 * time.Millisecond)
+
+	status, err := re.Status("queue")
+	assert.Nil(t, err)
+	assert.False(t, status.Running)
+	assert.False(t, status.Queued)
+	assert.Equal(t, 1, status.Runs)
+
+	stats, err := re.db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["queue"], 1)
+	assert.Equal(t, StatusCancelled, stats["queue"][0].Status)
+}
+
+func TestCatchUpRun(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	newSync := func(catchUp bool) *Sync {
+		return &Sync{
+			RsyncArgs:        String("-c"),
+			RsyncSource:      []string{"exit 0"},
+			RsyncDestination: String("0"),
+			Schedule:         String("0 0 1 1 *"),
+			CatchUp:          Bool(catchUp),
+		}
+	}
+
+	config := &Config{
+		RsyncPath: String("sh"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"missed":  newSync(true),
+			"ignored": newSync(false),
+			"current": newSync(true),
+			"new":     newSync(true),
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	// the yearly cron jobs fired more than a year ago except for current
+	longAgo := time.Now().AddDate(-2, 0, 0)
+	assert.Nil(t, db.SetLastScheduled("missed", longAgo))
+	assert.Nil(t, db.SetLastScheduled("ignored", longAgo))
+	assert.Nil(t, db.SetLastScheduled("current", time.Now()))
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	time.Sleep(300 * time.Millisecond)
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["missed"], 1)
+	assert.Len(t, stats["ignored"], 0)
+	assert.Len(t, stats["current"], 0)
+	assert.Len(t, stats["new"], 0)
+
+	// the catch up is stored so it only runs once and syncs without a stored time are measured from now
+	for _, name := range []string{"missed", "new"} {
+		last, err := db.LastScheduled(name)
+		assert.Nil(t, err)
+		assert.True(t, last.Equal(re.started))
+	}
+}
+
+func TestRunOnStart(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	newSync := func(runOnStart bool, catchUp bool) *Sync {
+		return &Sync{
+			RsyncArgs:        String("-c"),
+			RsyncSource:      []string{"exit 0"},
+			RsyncDestination: String("0"),
+			Schedule:         String("0 0 1 1 *"),
+			RunOnStart:       Bool(runOnStart),
+			CatchUp:          Bool(catchUp),
+		}
+	}
+
+	config := &Config{
+		RsyncPath: String("sh"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"start":  newSync(true, false),
+			"both":   newSync(true, true),
+			"manual": newSync(false, false),
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	assert.Nil(t, db.SetLastScheduled("both", time.Now().AddDate(-2, 0, 0)))
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	time.Sleep(300 * time.Millisecond)
+
+	// syncs with both catch_up and run_on_start only run once
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["start"], 1)
+	assert.Len(t, stats["both"], 1)
+	assert.NotEqual(t, StatusSkipped, stats["both"][0].Status)
+	assert.Len(t, stats["manual"], 0)
+}
+
+func TestJitterDelay(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		LogPath: String(dir),
+		LibPath: String(dir),
+		Syncs: map[string]*Sync{
+			"none": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+			"short": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("0 0 1 1 *"),
+				Jitter:           String("200ms"),
+			},
+			"long": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"/a/b/c"},
+				RsyncDestination: String("/d/e/f"),
+				Schedule:         String("0 0 1 1 *"),
+				Jitter:           String("1h"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+
+	start := time.Now()
+	assert.True(t, re.delay("none"))
+	assert.True(t, re.delay("short"))
+	assert.Less(t, time.Since(start), 300*time.Millisecond)
+
+	// stopping resync ends the delay
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		re.Stop()
+	}()
+	assert.False(t, re.delay("long"))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWindowsRun(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	today := strings.ToLower(time.Now().Weekday().String())
+	allDay := func(day string) []*Window {
+		return []*Window{{Days: []string{day}, Start: String("00:00"), End: String("00:00")}}
+	}
+
+	newSync := func() *Sync {
+		return &Sync{
+			RsyncArgs:        String("-c"),
+			RsyncSource:      []string{"exit 0"},
+			RsyncDestination: String("0"),
+			Schedule:         String("0 0 1 1 *"),
+		}
+	}
+
+	config := &Config{
+		RsyncPath: String("sh"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Blackout:  allDay(today),
+		Syncs: map[string]*Sync{
+			"blocked": newSync(),
+			"allowed": newSync(),
+		},
+	}
+	config.Syncs["allowed"].Blackout = []*Window{}
+	config.Syncs["allowed"].Windows = allDay(today)
+
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	re.job("blocked")
+	re.job("allowed")
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["blocked"], 0)
+	assert.Len(t, stats["allowed"], 1)
+
+	// manual runs ignore windows
+	started, err := re.Run("blocked")
+	assert.Nil(t, err)
+	assert.True(t, started)
+	time.Sleep(200 * time.Millisecond)
+
+	stats, err = db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["blocked"], 1)
+}
+
+func TestCloseWindows(t *testing.T) {
+	today := strings.ToLower(time.Now().Weekday().String())
+	newSync := func(windowClose string) *Sync {
+		return &Sync{
+			RsyncArgs:        String("-a"),
+			RsyncSource:      []string{"/a/b/c"},
+			RsyncDestination: String("/d/e/f"),
+			Schedule:         String("0 0 1 1 *"),
+			Windows:          []*Window{{Days: []string{today}, Start: String("00:00"), End: String("00:00")}},
+			WindowClose:      String(windowClose),
+		}
+	}
+
+	config := &Config{
+		Syncs: map[string]*Sync{
+			"finish": newSync(WindowCloseFinish),
+			"cancel": newSync(WindowCloseCancel),
+			"queued": newSync(WindowCloseFinish),
+			"manual": newSync(WindowCloseCancel),
+		},
+	}
+	err := config.validate()
+	assert.Nil(t, err)
+
+	re := New(config, nil, nil, nil)
+	for name := range config.Syncs {
+		ctx, cancel := context.WithCancel(context.Background())
+		re.syncs[name] = &runningSync{
+			name:      name,
+			scheduled: name != "manual",
+			queued:    name == "queued",
+			ctx:       ctx,
+			cancel:    cancel,
+		}
+	}
+
+	// nothing is cancelled while the windows are open
+	re.closeWindows(time.Now())
+	for _, sync := range re.syncs {
+		assert.Nil(t, sync.ctx.Err())
+	}
+
+	re.closeWindows(time.Now().AddDate(0, 0, 1))
+	assert.Nil(t, re.syncs["finish"].ctx.Err())
+	assert.Nil(t, re.syncs["manual"].ctx.Err())
+	assert.Error(t, re.syncs["cancel"].ctx.Err())
+	assert.Error(t, re.syncs["queued"].ctx.Err())
+	assert.Equal(t, EndCancelled, re.syncs["cancel"].stopped())
+}
+
+func TestEndReason(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	newSync := func(script string) *Sync {
+		return &Sync{
+			RsyncArgs:        String("-c"),
+			RsyncSource:      []string{script},
+			RsyncDestination: String("0"),
+			Schedule:         String("0 0 1 1 *"),
+		}
+	}
+
+	config := &Config{
+		RsyncPath: String("sh"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"completed": newSync("exit 1"),
+			"timed_out": newSync("sleep 5"),
+			"cancelled": newSync("sleep 5"),
+			"shutdown":  newSync("trap 'exit 20' TERM; while true; do sleep 0.01; done"),
+		},
+	}
+	config.Syncs["timed_out"].TimeLimit = String("100ms")
+
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	err = re.Start()
+	assert.Nil(t, err)
+
+	for name := range config.Syncs {
+		_, err := re.Run(name)
+		assert.Nil(t, err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	_, err = re.Cancel("cancelled")
+	assert.Nil(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	re.Stop()
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+	for name := range config.Syncs {
+		assert.Len(t, stats[name], 1)
+		assert.Equal(t, name, stats[name][0].EndReason)
+	}
+
+	assert.NotEqual(t, StatusCancelled, stats["completed"][0].Status)
+	assert.Equal(t, StatusTimedOut, stats["timed_out"][0].Status)
+	assert.Equal(t, StatusCancelled, stats["cancelled"][0].Status)
+
+	// the sync got to handle SIGTERM before it was stopped
+	assert.Equal(t, StatusCancelled, stats["shutdown"][0].Status)
+	assert.Equal(t, 20, stats["shutdown"][0].ExitCode)
+}
+
+func TestBandwidthRestart(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// Mon Jan 01 2024 during business hours
+	clock := atomic.Value{}
+	clock.Store(time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local))
+	bandwidthClock = func() time.Time {
+		return clock.Load().(time.Time)
+	}
+	defer func() {
+		bandwidthClock = time.Now
+	}()
+
+	out := filepath.Join(dir, "args")
+	config := &Config{
+		RsyncPath: String("./testdata/bwlimit.sh"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"src"},
+				RsyncDestination: String(out),
+				Schedule:         String("0 0 1 1 *"),
+				Bandwidth: []*Bandwidth{
+					{Window: Window{Days: []string{"mon-fri"}, Start: String("08:00"), End: String("18:00")}, Limit: String("2M")},
+					{Window: Window{Start: String("18:00"), End: String("08:00")}, Limit: String("0")},
+				},
+				BandwidthRestart: Bool(true),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	re := New(config, db, logger, NewEmailNotifier(config, db, logger))
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+
+	// business hours end while the first rsync is running
+	time.Sleep(200 * time.Millisecond)
+	clock.Store(time.Date(2024, 1, 1, 18, 0, 0, 0, time.Local))
+
+	assert.Eventually(t, func() bool {
+		status, err := re.Status("test")
+		return err == nil && status.Runs == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// rsync is restarted with the new limit without using a retry
+	b, err := os.ReadFile(out)
+	assert.Nil(t, err)
+	assert.Equal(t, "-a --bwlimit=2M src "+out+"\n-a --bwlimit=0 src "+out+"\n", string(b))
+
+	stats, err := db.List()
+	assert.Nil(t, err)
+	assert.Len(t, stats["test"], 1)
+	assert.Equal(t, StatusSuccess, stats["test"][0].Status)
+	assert.Len(t, stats["test"][0].Attempts, 2)
+	assert.NotEmpty(t, stats["test"][0].Attempts[0].Error)
+	assert.Empty(t, stats["test"][0].Attempts[1].Error)
+}
@@ -0,0 +1,508 @@
+package resync
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etherlabsio/healthcheck/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// RunResult is returned by the HTTP server when a sync is triggered.
+type RunResult struct {
+	Name    string
+	Started bool
+}
+
+// CancelResult is returned by the HTTP server when a sync is cancelled.
+type CancelResult struct {
+	Name      string
+	Cancelled bool
+}
+
+// PauseResult is returned by the HTTP server when a sync is paused or resumed.
+type PauseResult struct {
+	Name   string
+	Paused bool
+}
+
+// Server is an http.Handler that provides health checks and controls for a running Resync.
+type Server struct {
+	config *Config
+	resync *Resync
+	db     DB
+	logger Logger
+	mux    *http.ServeMux
+
+	// mu guards the socket being served by ServeSocket
+	mu         sync.Mutex
+	socket     net.Listener
+	socketPath string
+}
+
+// NewServer creates a Server using config, re, db, and logger.
+func NewServer(config *Config, re *Resync, db DB, logger Logger) *Server {
+	s := &Server{
+		config: config,
+		resync: re,
+		db:     db,
+		logger: logger,
+		mux:    http.NewServeMux(),
+	}
+
+	s.mux.Handle("/live", healthcheck.Handler(
+		healthcheck.WithTimeout(5*time.Second),
+		healthcheck.WithChecker(
+			"live", healthcheck.CheckerFunc(
+				func(ctx context.Context) error {
+					return nil
+				},
+			),
+		),
+	))
+
+	// get most recent status for each sync and if any have failed then return an error
+	s.mux.Handle("/health", healthcheck.Handler(
+		healthcheck.WithTimeout(5*time.Second),
+		healthcheck.WithChecker(
+			"health", healthcheck.CheckerFunc(
+				func(ctx context.Context) error {
+					return s.resync.HealthAll()
+				},
+			),
+		),
+	))
+
+	for name := range config.Syncs {
+		// make sure variables used in different goroutine (CheckerFunc) aren't shadowed
+		func(name string) {
+			s.mux.Handle("/health/"+name, healthcheck.Handler(
+				healthcheck.WithTimeout(5*time.Second),
+				healthcheck.WithChecker(
+					name, healthcheck.CheckerFunc(
+						func(ctx context.Context) error {
+							return s.resync.Health(name)
+						},
+					),
+				),
+			))
+		}(name)
+	}
+
+	s.mux.HandleFunc("/", s.handleDashboard)
+	s.mux.HandleFunc("/events", s.handleEvents)
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+	s.mux.HandleFunc("/stats", s.handleStats)
+	s.mux.HandleFunc("/syncs", s.handleStatuses)
+	s.mux.HandleFunc("/syncs/", s.handleSync)
+
+	return s
+}
+
+// ServeHTTP implements the http.Handler interface. When authentication is configured every endpoint except /live
+// requires a valid bearer token or basic auth user. Requests that change state are refused if a browser sent them
+// from another site since browsers send basic auth credentials with them automatically.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/live" && !s.authorized(r) {
+		if len(s.config.HTTP.Users) > 0 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="resync"`)
+		} else {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="resync"`)
+		}
+		writeError(w, http.StatusUnauthorized, errors.New("Unauthorized"))
+		return
+	}
+
+	if !sameOrigin(r) {
+		writeError(w, http.StatusForbidden, errors.New("Cross-origin request refused"))
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// sameOrigin returns false if r changes state and its Origin or Referer is a different host than the one it was sent
+// to. Browsers always send one of them with these requests. Other clients usually send neither so they're allowed.
+func sameOrigin(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+
+	if source == "" {
+		return true
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return false
+	}
+
+	return u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// authorized returns true if authentication isn't configured or r has valid credentials.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.config.HTTP == nil || !s.config.HTTP.Auth() {
+		return true
+	}
+
+	if user, pass, ok := r.BasicAuth(); ok {
+		expected, ok := s.config.HTTP.Users[user]
+		return ok && subtle.ConstantTimeCompare([]byte(pass), []byte(expected)) == 1
+	}
+
+	if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); token != r.Header.Get("Authorization") {
+		for _, expected := range s.config.HTTP.Tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// handleEvents streams sync lifecycle events as server-sent events until the client disconnects.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("Streaming unsupported"))
+		return
+	}
+
+	events, unsubscribe := s.resync.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(30 * time.Second)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Errorf("HTTP: failed to marshal event: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			flusher.Flush()
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := s.resync.WriteMetrics(w); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+	}
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	stats, err := s.db.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) handleStatuses(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	statuses, err := s.resync.Statuses()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// handleSync routes requests for /syncs/{name} and /syncs/{name}/{action}.
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/syncs/"), "/"), "/")
+	if len(parts) > 2 || parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	name := parts[0]
+	if _, err := s.config.GetSync(name); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if len(parts) == 1 {
+		s.handleStatus(w, r, name)
+		return
+	}
+
+	switch parts[1] {
+	case "run":
+		s.handleRun(w, r, name)
+	case "cancel":
+		s.handleCancel(w, r, name)
+	case "pause":
+		s.handlePause(w, r, name, true)
+	case "resume":
+		s.handlePause(w, r, name, false)
+	case "stdout":
+		s.handleLog(w, r, name, s.logger.Stdout)
+	case "stderr":
+		s.handleLog(w, r, name, s.logger.Stderr)
+	case "tail":
+		s.handleTail(w, r, name)
+	case "manifests":
+		s.handleManifests(w, r, name)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request, name string) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	status, err := s.resync.Status(name)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request, name string) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	started, err := s.resync.Run(name)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	code := http.StatusAccepted
+	if !started {
+		code = http.StatusConflict
+	}
+
+	writeJSON(w, code, RunResult{
+		Name:    name,
+		Started: started,
+	})
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request, name string) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	cancelled, err := s.resync.Cancel(name)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	code := http.StatusAccepted
+	if !cancelled {
+		code = http.StatusConflict
+	}
+
+	writeJSON(w, code, CancelResult{
+		Name:      name,
+		Cancelled: cancelled,
+	})
+}
+
+// handlePause pauses the sync if pause is true and resumes it otherwise.
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request, name string, pause bool) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var err error
+	if pause {
+		_, err = s.resync.Pause(name)
+	} else {
+		_, err = s.resync.Resume(name)
+	}
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PauseResult{
+		Name:   name,
+		Paused: pause,
+	})
+}
+
+// handleManifests returns the stored manifests for name. The path and change query parameters filter the manifests
+// to the runs that changed path.
+func (s *Server) handleManifests(w http.ResponseWriter, r *http.Request, name string) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	query := r.URL.Query()
+	manifests, err := s.resync.Manifests(name, query.Get("path"), Change(query.Get("change")))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, manifests)
+}
+
+// handleLog writes the latest log for name returned by open.
+func (s *Server) handleLog(w http.ResponseWriter, r *http.Request, name string, open func(string) (io.ReadCloser, error)) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	f, err := open(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := io.Copy(w, f); err != nil {
+		log.Errorf("HTTP: failed to write log for %s: %v", name, err)
+	}
+}
+
+// handleTail streams the log of a running sync until the sync finishes. The stream query parameter selects stdout
+// or stderr and defaults to stdout.
+func (s *Server) handleTail(w http.ResponseWriter, r *http.Request, name string) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("Streaming unsupported"))
+		return
+	}
+
+	stream := r.URL.Query().Get("stream")
+	if stream == "" {
+		stream = "stdout"
+	}
+
+	if stream != "stdout" && stream != "stderr" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid log stream: %s", stream))
+		return
+	}
+
+	running, err := s.resync.Running()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	if !contains(running, name) {
+		writeError(w, http.StatusConflict, ErrSyncNotRunning)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	fw := &flushWriter{
+		w:       w,
+		flusher: flusher,
+	}
+
+	// the sync may finish before Tail checks if it's running so ErrSyncNotRunning is expected
+	if err := s.resync.Tail(r.Context(), name, stream, fw); err != nil && !errors.Is(err, ErrSyncNotRunning) {
+		log.Errorf("HTTP: failed to tail %s for %s: %v", stream, name, err)
+	}
+}
+
+// flushWriter flushes the response after every write so streamed output is sent immediately.
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.flusher.Flush()
+	return n, err
+}
+
+// allowMethod writes a 405 response and returns false if the request method isn't method.
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("Method %s not allowed", r.Method))
+	return false
+}
+
+// writeJSON writes v as the JSON encoded response body.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("HTTP: failed to write response: %v", err)
+	}
+}
+
+// writeError writes err as a JSON encoded response body.
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, struct {
+		Error string
+	}{
+		Error: err.Error(),
+	})
+}
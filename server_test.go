@@ -0,0 +1,396 @@
+package resync
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer returns a running Resync with a single sync named test that sleeps for one second.
+func newTestServer(t *testing.T) (*Resync, *Server, func()) {
+	re, cleanup := newTestResync(t, &Config{
+		RsyncPath: String("sleep"),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("1"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+		},
+	})
+
+	return re, NewServer(re.config, re, re.db, re.logger), cleanup
+}
+
+func TestServerRun(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/syncs/test/run", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	result := RunResult{}
+	err := json.NewDecoder(w.Body).Decode(&result)
+	assert.Nil(t, err)
+	assert.Equal(t, "test", result.Name)
+	assert.True(t, result.Started)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/syncs/test/run", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	result = RunResult{}
+	err = json.NewDecoder(w.Body).Decode(&result)
+	assert.Nil(t, err)
+	assert.False(t, result.Started)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs/test/run", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/syncs/foo/run", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/syncs/test/foo", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServerHealth(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/live", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/test", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/foo", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	err := server.db.Insert(NewStat("test", "").Finish(errors.New("fail")))
+	assert.Nil(t, err)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/test", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestServerCancel(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/syncs/test/cancel", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/syncs/test/run", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/syncs/test/cancel", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	result := CancelResult{}
+	err := json.NewDecoder(w.Body).Decode(&result)
+	assert.Nil(t, err)
+	assert.Equal(t, "test", result.Name)
+	assert.True(t, result.Cancelled)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs/test/cancel", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestServerStatus(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	statuses := []SyncStatus{}
+	err := json.NewDecoder(w.Body).Decode(&statuses)
+	assert.Nil(t, err)
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, "test", statuses[0].Name)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs/test", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	status := SyncStatus{}
+	err = json.NewDecoder(w.Body).Decode(&status)
+	assert.Nil(t, err)
+	assert.Equal(t, "test", status.Name)
+	assert.Equal(t, "0 0 1 1 *", status.Schedule)
+	assert.False(t, status.Running)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs/foo", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/syncs", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestServerMetrics(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `resync_sync_running{sync="test"} 0`)
+}
+
+func TestServerLogs(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs/test/stdout", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	stdout, stderr, err := server.logger.Rotate("test")
+	assert.Nil(t, err)
+	_, err = stdout.Write([]byte("STDOUT"))
+	assert.Nil(t, err)
+	_, err = stderr.Write([]byte("STDERR"))
+	assert.Nil(t, err)
+	assert.Nil(t, stdout.Close())
+	assert.Nil(t, stderr.Close())
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs/test/stdout", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "STDOUT", w.Body.String())
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs/test/stderr", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "STDERR", w.Body.String())
+}
+
+func TestServerEvents(t *testing.T) {
+	re, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+
+	scanner := bufio.NewScanner(resp.Body)
+	assert.True(t, scanner.Scan())
+	assert.Equal(t, "event: started", scanner.Text())
+	assert.True(t, scanner.Scan())
+	assert.True(t, strings.HasPrefix(scanner.Text(), "data: "))
+
+	event := Event{}
+	err = json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data: ")), &event)
+	assert.Nil(t, err)
+	assert.Equal(t, EventStarted, event.Type)
+	assert.Equal(t, "test", event.Name)
+}
+
+func TestServerAuth(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	server.config.HTTP = &HTTP{
+		Tokens: []string{"token"},
+		Users:  map[string]string{"me": "pass"},
+	}
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/live", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Basic realm="resync"`, w.Header().Get("WWW-Authenticate"))
+
+	r := httptest.NewRequest(http.MethodGet, "/health", nil)
+	r.Header.Set("Authorization", "Bearer token")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/health", nil)
+	r.Header.Set("Authorization", "Bearer wrong")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/health", nil)
+	r.Header.Set("Authorization", "token")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/health", nil)
+	r.SetBasicAuth("me", "pass")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/health", nil)
+	r.SetBasicAuth("me", "wrong")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/health", nil)
+	r.SetBasicAuth("you", "pass")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	server.config.HTTP.Users = nil
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="resync"`, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestServerCrossOrigin(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	// a page on another site can't run or cancel syncs
+	for _, header := range []string{"Origin", "Referer"} {
+		r := httptest.NewRequest(http.MethodPost, "/syncs/test/run", nil)
+		r.Header.Set(header, "https://evil.example.org/page")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/syncs/test/cancel", nil)
+	r.Header.Set("Origin", "null")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// reads are still allowed
+	r = httptest.NewRequest(http.MethodGet, "/syncs/test", nil)
+	r.Header.Set("Origin", "https://evil.example.org")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the dashboard's own requests are allowed
+	r = httptest.NewRequest(http.MethodPost, "/syncs/test/run", nil)
+	r.Header.Set("Origin", "http://"+r.Host)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestServerPause(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/syncs/test/pause", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	result := PauseResult{}
+	err := json.NewDecoder(w.Body).Decode(&result)
+	assert.Nil(t, err)
+	assert.Equal(t, "test", result.Name)
+	assert.True(t, result.Paused)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/syncs/test/resume", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	result = PauseResult{}
+	err = json.NewDecoder(w.Body).Decode(&result)
+	assert.Nil(t, err)
+	assert.False(t, result.Paused)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs/test/pause", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestServerStats(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	err := server.db.Insert(NewStat("test", "").Finish(nil))
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	stats := make(map[string][]Stat)
+	err = json.NewDecoder(w.Body).Decode(&stats)
+	assert.Nil(t, err)
+	assert.Len(t, stats["test"], 1)
+	assert.True(t, stats["test"][0].Success())
+}
+
+func TestServerManifests(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	manifest := NewManifest(NewStat("test", ""))
+	manifest.Entries = append(manifest.Entries, ManifestEntry{Change: ChangeDeleted, Path: "data/x", Item: "*deleting"})
+	err := server.db.InsertManifest(manifest)
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs/test/manifests", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	manifests := make([]Manifest, 0)
+	err = json.NewDecoder(w.Body).Decode(&manifests)
+	assert.Nil(t, err)
+	assert.Len(t, manifests, 1)
+	assert.Len(t, manifests[0].Entries, 1)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs/test/manifests?path=data/y", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/syncs/test/manifests?change=invalid", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
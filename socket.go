@@ -0,0 +1,98 @@
+package resync
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrSocketInUse is returned by ServeSocket when another resync is already serving the socket.
+var ErrSocketInUse = errors.New("Socket: another resync is already listening")
+
+// SocketPath returns the path to the control socket in the configured lib path.
+func SocketPath(config *Config) string {
+	return filepath.Join(StringValue(config.LibPath), "resync.sock")
+}
+
+// ServeSocket serves the same endpoints as ServeHTTP on a unix socket at path. Authentication isn't required
+// because the socket's file permissions only allow the user running resync to connect. ServeSocket blocks until
+// the listener fails or Close is called. It returns ErrSocketInUse if another resync is serving the socket.
+func (s *Server) ServeSocket(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("Socket: failed to create socket directory: %w", err)
+	}
+
+	// never take the socket from a resync that's still running
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%w on %s", ErrSocketInUse, path)
+	}
+
+	// remove a socket left behind by a previous run
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Socket: failed to remove old socket: %w", err)
+	}
+
+	// create the socket in a private directory and move it into place once only the owner can connect to it
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".resync")
+	if err != nil {
+		return fmt.Errorf("Socket: failed to create socket directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, filepath.Base(path))
+	l, err := net.Listen("unix", tmp)
+	if err != nil {
+		return fmt.Errorf("Socket: failed to listen: %w", err)
+	}
+	// the listener would unlink tmp on close instead of path
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	defer l.Close()
+
+	if err := os.Chmod(tmp, 0600); err != nil {
+		return fmt.Errorf("Socket: failed to set socket permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("Socket: failed to move socket into place: %w", err)
+	}
+	os.Remove(dir)
+
+	s.mu.Lock()
+	s.socket = l
+	s.socketPath = path
+	s.mu.Unlock()
+
+	err = http.Serve(l, s.mux)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.socket == nil {
+		// Close was called
+		return nil
+	}
+	s.socket = nil
+	os.Remove(path)
+	return err
+}
+
+// Close stops serving the socket and removes it. Close does nothing if ServeSocket isn't running.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.socket == nil {
+		return nil
+	}
+
+	err := s.socket.Close()
+	s.socket = nil
+	if rerr := os.Remove(s.socketPath); rerr != nil && !os.IsNotExist(rerr) && err == nil {
+		err = rerr
+	}
+	return err
+}
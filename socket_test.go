@@ -0,0 +1,158 @@
+package resync
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSocket(t *testing.T) {
+	re, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	// auth isn't required on the socket
+	re.config.HTTP = &HTTP{
+		Tokens: []string{"token"},
+	}
+
+	path := SocketPath(re.config)
+	assert.Equal(t, filepath.Join(StringValue(re.config.LibPath), "resync.sock"), path)
+
+	// stale sockets are replaced
+	err := os.WriteFile(path, []byte{}, 0600)
+	assert.Nil(t, err)
+
+	go server.ServeSocket(path)
+	time.Sleep(100 * time.Millisecond)
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	client := NewSocketClient(re.config)
+	ctx := context.Background()
+
+	statuses, err := client.Statuses(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, "test", statuses[0].Name)
+
+	err = client.Pause(ctx, "test")
+	assert.Nil(t, err)
+
+	statuses, err = client.Statuses(ctx)
+	assert.Nil(t, err)
+	assert.True(t, statuses[0].Paused)
+
+	err = client.Resume(ctx, "test")
+	assert.Nil(t, err)
+
+	statuses, err = client.Statuses(ctx)
+	assert.Nil(t, err)
+	assert.False(t, statuses[0].Paused)
+
+	err = client.Pause(ctx, "foo")
+	assert.Error(t, err)
+
+	err = client.Resume(ctx, "foo")
+	assert.Error(t, err)
+
+	started, err := client.Run(ctx, "test")
+	assert.Nil(t, err)
+	assert.True(t, started)
+
+	started, err = client.Run(ctx, "test")
+	assert.Nil(t, err)
+	assert.False(t, started)
+
+	_, err = client.Run(ctx, "foo")
+	assert.Error(t, err)
+
+	cancelled, err := client.Cancel(ctx, "test")
+	assert.Nil(t, err)
+	assert.True(t, cancelled)
+
+	time.Sleep(100 * time.Millisecond)
+
+	cancelled, err = client.Cancel(ctx, "test")
+	assert.Nil(t, err)
+	assert.False(t, cancelled)
+
+	stats, err := client.Stats(ctx)
+	assert.Nil(t, err)
+	// the skipped run is stored too
+	assert.Len(t, stats["test"], 2)
+	assert.Equal(t, StatusSkipped, stats["test"][0].Status)
+	assert.Equal(t, StatusCancelled, stats["test"][1].Status)
+}
+
+func TestSocketTail(t *testing.T) {
+	re, cleanup := newTestResync(t, newTailConfig())
+	defer cleanup()
+
+	// tailing through the socket doesn't need the http server or its credentials
+	server := NewServer(re.config, re, re.db, re.logger)
+	defer server.Close()
+	go server.ServeSocket(SocketPath(re.config))
+	time.Sleep(100 * time.Millisecond)
+
+	_, err := re.Run("test")
+	assert.Nil(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	stdout := &bytes.Buffer{}
+	err = NewSocketClient(re.config).Tail(context.Background(), "test", "stdout", stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "one\nthree\n", stdout.String())
+}
+
+func TestSocketInUse(t *testing.T) {
+	re, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	path := SocketPath(re.config)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.ServeSocket(path)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// a second resync can't take the socket from the running one
+	err := NewServer(re.config, re, re.db, re.logger).ServeSocket(path)
+	assert.ErrorIs(t, err, ErrSocketInUse)
+
+	_, err = NewSocketClient(re.config).Statuses(context.Background())
+	assert.Nil(t, err)
+
+	// the private directory the socket was created in is removed
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(path), ".resync*"))
+	assert.Nil(t, err)
+	assert.Len(t, matches, 0)
+
+	// the socket is removed on close
+	err = server.Close()
+	assert.Nil(t, err)
+	assert.Nil(t, <-errc)
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSocketClientWithoutServer(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	client := NewSocketClient(&Config{
+		LibPath: String(dir),
+	})
+
+	_, err = client.Stats(context.Background())
+	assert.Error(t, err)
+}
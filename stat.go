@@ -1,42 +1,205 @@
 package resync
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
-// Stat defines basic statistics for a single sync. Stats are stored so that historical data from past syncs
-// can be viewed.
+// The reasons a run ended. They're stored in Stat.EndReason.
+const (
+	// EndCompleted means rsync exited on its own whether it succeeded or failed.
+	EndCompleted = "completed"
+
+	// EndTimedOut means the run was stopped because it exceeded its time limit.
+	EndTimedOut = "timed_out"
+
+	// EndCancelled means the run was cancelled, replaced by a new run, or stopped because its window closed.
+	EndCancelled = "cancelled"
+
+	// EndShutdown means the run was stopped because resync was shutting down.
+	EndShutdown = "shutdown"
+
+	// EndPreCommandFailed means rsync wasn't run because pre_command failed.
+	EndPreCommandFailed = "pre_command_failed"
+)
+
+// Status is how a run of a sync finished.
+type Status string
+
+// The statuses a run can finish with.
+const (
+	// StatusSuccess means rsync succeeded.
+	StatusSuccess Status = "success"
+
+	// StatusWarning means rsync exited with one of the sync's warning exit codes and the run counts as a success.
+	StatusWarning Status = "warning"
+
+	// StatusFailed means rsync or pre_command failed on its own.
+	StatusFailed Status = "failed"
+
+	// StatusTimedOut means the run was stopped because it exceeded its time limit.
+	StatusTimedOut Status = "timed_out"
+
+	// StatusCancelled means the run was cancelled or stopped by a shutdown instead of failing on its own.
+	StatusCancelled Status = "cancelled"
+
+	// StatusSkipped means the sync wasn't run because it was already running.
+	StatusSkipped Status = "skipped"
+)
+
+// Stat defines basic statistics for a single sync. Stats are stored so that historical data from past syncs can be
+// viewed. Status is empty until the run finishes. StartTime and EndTime are the unformatted Start and End. Attempts
+// has an entry for every time rsync was run during the sync including retries. ExitCode, ExitDescription, and Error
+// describe how the last attempt exited. ExitCode is only ever an rsync exit code and is 0 when rsync wasn't run. TransferStats are parsed from the output of the last attempt when rsync_args
+// contains --stats. Queued is how long the sync waited to run because of max_concurrent and isn't part of Duration.
+// EndReason is one of the End constants and is empty for skipped runs.
 type Stat struct {
-	Name     string
-	Success  bool
-	Start    string
-	End      string
-	Duration time.Duration
-	format   string
-	start    time.Time
-	end      time.Time
+	TransferStats
+	Name            string
+	Status          Status
+	EndReason       string
+	ExitCode        int
+	ExitDescription string
+	Error           string
+	Start           string
+	End             string
+	Duration        time.Duration
+	Queued          time.Duration
+	StartTime       time.Time
+	EndTime         time.Time
+	Attempts        []Attempt
+	format          string
+}
+
+// Success returns true if the run succeeded including with a warning.
+func (s Stat) Success() bool {
+	return s.Status == StatusSuccess || s.Status == StatusWarning
 }
 
-// Finish sets the Success based on err, End based on the current time, and Duration based on Start and End.
+// UnmarshalJSON decodes a stat. Stats stored before Status existed have their Status set from the boolean fields
+// they were stored with.
+func (s *Stat) UnmarshalJSON(data []byte) error {
+	// stat has the same fields as Stat without the UnmarshalJSON method
+	type stat Stat
+
+	legacy := struct {
+		*stat
+		Success   bool
+		Warning   bool
+		Cancelled bool
+		TimedOut  bool
+		Skipped   bool
+	}{
+		stat: (*stat)(s),
+	}
+
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+
+	if s.Status != "" {
+		return nil
+	}
+
+	switch {
+	case legacy.Skipped:
+		s.Status = StatusSkipped
+	case legacy.Cancelled:
+		s.Status = StatusCancelled
+	case legacy.TimedOut:
+		s.Status = StatusTimedOut
+	case legacy.Warning:
+		s.Status = StatusWarning
+	case legacy.Success:
+		s.Status = StatusSuccess
+	default:
+		s.Status = StatusFailed
+	}
+	return nil
+}
+
+// RsyncRan returns true if rsync was run at least once during the sync.
+func (s Stat) RsyncRan() bool {
+	return len(s.Attempts) > 0
+}
+
+// Finish sets the Status, ExitCode, ExitDescription, and Error based on err, End based on the current time, and
+// Duration based on Start and End.
 func (s Stat) Finish(err error) Stat {
 	if err == nil {
-		s.Success = true
+		s.Status = StatusSuccess
+	} else {
+		s.Status = StatusFailed
+		s.Error = err.Error()
 	}
 
-	s.end = time.Now()
-	s.End = s.end.Format(s.format)
-	s.Duration = time.Since(s.start)
+	s.ExitCode = exitCode(err)
+	s.ExitDescription = ExitCodeDescription(s.ExitCode)
+
+	s.EndTime = time.Now()
+	s.End = s.EndTime.Format(s.format)
+	s.Duration = s.EndTime.Sub(s.StartTime)
 	return s
 }
 
-// NewStat creates a new Stat with Name set to name, Success set to false, and Start set to
-// the current time.
+// Skip sets the Status, Error, and ExitDescription based on err, which is the reason the sync was skipped, and End to
+// Start.
+func (s Stat) Skip(err error) Stat {
+	s.Status = StatusSkipped
+	s.Error = err.Error()
+	s.ExitDescription = "Skipped because " + s.Error
+
+	s.EndTime = s.StartTime
+	s.End = s.Start
+	return s
+}
+
+// NewStat creates a new Stat with Name set to name and Start set to the current time.
 func NewStat(name string, format string) Stat {
 	start := time.Now()
 
 	return Stat{
-		Name:    name,
-		Success: false,
-		Start:   start.Format(format),
-		start:   start,
-		format:  format,
+		Name:      name,
+		Start:     start.Format(format),
+		StartTime: start,
+		format:    format,
+	}
+}
+
+// Attempt defines basic statistics for a single run of rsync during a sync. Error is empty if the attempt succeeded.
+type Attempt struct {
+	Start     string
+	End       string
+	Duration  time.Duration
+	StartTime time.Time
+	EndTime   time.Time
+	ExitCode  int
+	Error     string
+	format    string
+}
+
+// Finish sets the ExitCode and Error based on err, End based on the current time, and Duration based on Start and
+// End.
+func (a Attempt) Finish(err error) Attempt {
+	if err != nil {
+		a.Error = err.Error()
+	}
+
+	a.ExitCode = exitCode(err)
+
+	a.EndTime = time.Now()
+	a.End = a.EndTime.Format(a.format)
+	a.Duration = a.EndTime.Sub(a.StartTime)
+	return a
+}
+
+// NewAttempt creates a new Attempt with Start set to the current time.
+func NewAttempt(format string) Attempt {
+	start := time.Now()
+
+	return Attempt{
+		Start:     start.Format(format),
+		StartTime: start,
+		format:    format,
 	}
 }
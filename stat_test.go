@@ -1,7 +1,9 @@
 package resync
 
 import (
+	"encoding/json"
 	"errors"
+	"os/exec"
 	"testing"
 	"time"
 
@@ -11,27 +13,89 @@ import (
 func TestStat(t *testing.T) {
 	stat := NewStat("SUCCESS", "Mon Jan 02 03:04:05 PM MST")
 	assert.Equal(t, stat.Name, "SUCCESS")
-	assert.False(t, stat.Success)
+	assert.False(t, stat.Success())
 	assert.Equal(t, stat.End, "")
 	assert.Equal(t, stat.Duration, time.Duration(0))
 
 	stat = stat.Finish(nil)
 	assert.Equal(t, stat.Name, "SUCCESS")
-	assert.True(t, stat.Success)
+	assert.Equal(t, StatusSuccess, stat.Status)
+	assert.True(t, stat.Success())
 	assert.NotEqual(t, stat.End, "")
 	assert.NotEqual(t, stat.Duration, time.Duration(0))
+	assert.False(t, stat.StartTime.IsZero())
+	assert.False(t, stat.EndTime.IsZero())
+	assert.Equal(t, stat.EndTime.Sub(stat.StartTime), stat.Duration)
+	assert.Equal(t, 0, stat.ExitCode)
+	assert.Equal(t, "Success", stat.ExitDescription)
+	assert.Equal(t, "", stat.Error)
 }
 
 func TestErrorStat(t *testing.T) {
 	stat := NewStat("FAIL", "Mon Jan 02 03:04:05 PM MST")
 	assert.Equal(t, stat.Name, "FAIL")
-	assert.False(t, stat.Success)
+	assert.False(t, stat.Success())
 	assert.Equal(t, stat.End, "")
 	assert.Equal(t, stat.Duration, time.Duration(0))
 
 	stat = stat.Finish(errors.New("fail"))
 	assert.Equal(t, stat.Name, "FAIL")
-	assert.False(t, stat.Success)
+	assert.False(t, stat.Success())
 	assert.NotEqual(t, stat.End, "")
 	assert.NotEqual(t, stat.Duration, time.Duration(0))
+	assert.Equal(t, StatusFailed, stat.Status)
+	assert.Equal(t, -1, stat.ExitCode)
+	assert.Equal(t, "fail", stat.Error)
+}
+
+func TestExitStat(t *testing.T) {
+	stat := NewStat("PARTIAL", "Mon Jan 02 03:04:05 PM MST")
+
+	stat = stat.Finish(exec.Command("sh", "-c", "exit 23").Run())
+	assert.False(t, stat.Success())
+	assert.Equal(t, 23, stat.ExitCode)
+	assert.Equal(t, "Partial transfer due to error", stat.ExitDescription)
+	assert.Equal(t, "exit status 23", stat.Error)
+}
+
+func TestSkipStat(t *testing.T) {
+	stat := NewStat("SKIP", "Mon Jan 02 03:04:05 PM MST")
+
+	stat = stat.Skip(errAlreadyRunning)
+	assert.False(t, stat.Success())
+	assert.Equal(t, StatusSkipped, stat.Status)
+	assert.Equal(t, "it's already running", stat.Error)
+	assert.Equal(t, "Skipped because it's already running", stat.ExitDescription)
+	assert.Equal(t, stat.Start, stat.End)
+	assert.Equal(t, time.Duration(0), stat.Duration)
+}
+
+func TestStatLegacyJSON(t *testing.T) {
+	tests := map[string]Status{
+		`{"Success":true}`:                StatusSuccess,
+		`{"Success":true,"Warning":true}`: StatusWarning,
+		`{"Cancelled":true}`:              StatusCancelled,
+		`{"TimedOut":true}`:               StatusTimedOut,
+		`{"Skipped":true}`:                StatusSkipped,
+		`{}`:                              StatusFailed,
+		`{"Status":"skipped"}`:            StatusSkipped,
+	}
+
+	for data, status := range tests {
+		stat := Stat{}
+		err := json.Unmarshal([]byte(data), &stat)
+		assert.Nil(t, err)
+		assert.Equal(t, status, stat.Status, data)
+	}
+
+	stat := Stat{}
+	err := json.Unmarshal([]byte(`{"Name":"test","Success":true,"ExitCode":0}`), &stat)
+	assert.Nil(t, err)
+	assert.Equal(t, "test", stat.Name)
+	assert.True(t, stat.Success())
+
+	data, err := json.Marshal(NewStat("test", "").Finish(nil))
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"Status":"success"`)
+	assert.NotContains(t, string(data), `"Success":`)
 }
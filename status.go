@@ -0,0 +1,107 @@
+package resync
+
+import (
+	"sort"
+	"time"
+)
+
+// SyncStatus describes the configuration and current state of a single sync. Schedule is empty for syncs that run after
+// the syncs in DependsOn. Next and Prev are the next and previous times the sync's cron job fires and are zero if
+// unknown. Queued is true while a run of the sync waits because of max_concurrent or its overlap policy. Paused is true
+// if the cron job is paused. Runs, Failures, and TimedOut count the finished runs since resync started and Skipped
+// counts the runs skipped because the sync was already running. LastStat is the latest stored stat that wasn't skipped
+// and is nil if there isn't one. Progress is nil unless the sync is running and rsync has reported its progress.
+type SyncStatus struct {
+	Name      string
+	Schedule  string
+	DependsOn []string
+	TimeLimit time.Duration
+	Running   bool
+	Queued    bool
+	Paused    bool
+	Next      time.Time
+	Prev      time.Time
+	Runs      int
+	Failures  int
+	TimedOut  int
+	Skipped   int
+	LastStat  *Stat
+	Progress  *Progress
+}
+
+// Statuses returns the status of every configured sync sorted by name.
+func (re *Resync) Statuses() ([]SyncStatus, error) {
+	statMap, err := re.db.List()
+	if err != nil {
+		return nil, err
+	}
+
+	return re.statuses(statMap)
+}
+
+// statuses returns the status of every configured sync sorted by name using the stats in statMap.
+func (re *Resync) statuses(statMap map[string][]Stat) ([]SyncStatus, error) {
+	states, err := re.states()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]SyncStatus, 0, len(re.config.Syncs))
+	for name := range re.config.Syncs {
+		statuses = append(statuses, re.status(name, states[name], statMap[name]))
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses, nil
+}
+
+// Status returns the status of the sync with name.
+func (re *Resync) Status(name string) (SyncStatus, error) {
+	if _, err := re.config.GetSync(name); err != nil {
+		return SyncStatus{}, err
+	}
+
+	states, err := re.states()
+	if err != nil {
+		return SyncStatus{}, err
+	}
+
+	statMap, err := re.db.List()
+	if err != nil {
+		return SyncStatus{}, err
+	}
+
+	return re.status(name, states[name], statMap[name]), nil
+}
+
+// status fills in state, which comes from the main loop, using the config, cron entries, and stats.
+func (re *Resync) status(name string, state SyncStatus, stats []Stat) SyncStatus {
+	sync := re.config.Syncs[name]
+
+	status := state
+	status.Name = name
+	status.Schedule = StringValue(sync.Schedule)
+	status.DependsOn = sync.DependsOn
+
+	if timeLimit, err := re.config.GetTimeLimit(name); err == nil {
+		status.TimeLimit = timeLimit
+	}
+
+	if id, ok := re.entries[name]; ok {
+		entry := re.crontab.Entry(id)
+		status.Next = entry.Next
+		status.Prev = entry.Prev
+	}
+
+	for i := range stats {
+		if stats[i].Status != StatusSkipped {
+			status.LastStat = &stats[i]
+			break
+		}
+	}
+
+	return status
+}
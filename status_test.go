@@ -0,0 +1,86 @@
+package resync
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		RsyncPath: String("sleep"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		TimeLimit: String("1h"),
+		Syncs: map[string]*Sync{
+			"b": {
+				RsyncArgs:        String("1"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+				TimeLimit:        String("5m"),
+			},
+			"a": {
+				RsyncArgs:        String("0"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := NewFSLogger(config)
+	notifier := NewEmailNotifier(config, db, logger)
+	re := New(config, db, logger, notifier)
+
+	_, err = re.Statuses()
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	_, err = re.Status("foo")
+	assert.Error(t, err)
+
+	status, err := re.Status("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", status.Name)
+	assert.Equal(t, "0 0 1 1 *", status.Schedule)
+	assert.Equal(t, time.Hour, status.TimeLimit)
+	assert.False(t, status.Running)
+	assert.False(t, status.Next.IsZero())
+	assert.True(t, status.Prev.IsZero())
+	assert.Nil(t, status.LastStat)
+
+	_, err = re.Run("a")
+	assert.Nil(t, err)
+	_, err = re.Run("b")
+	assert.Nil(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	statuses, err := re.Statuses()
+	assert.Nil(t, err)
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, "a", statuses[0].Name)
+	assert.False(t, statuses[0].Running)
+	assert.NotNil(t, statuses[0].LastStat)
+	assert.True(t, statuses[0].LastStat.Success())
+	assert.Equal(t, "b", statuses[1].Name)
+	assert.Equal(t, 5*time.Minute, statuses[1].TimeLimit)
+	assert.True(t, statuses[1].Running)
+	assert.Nil(t, statuses[1].LastStat)
+}
@@ -0,0 +1,105 @@
+package resync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrSyncNotRunning is returned when following the logs of a sync that isn't running.
+var ErrSyncNotRunning = errors.New("Sync isn't running")
+
+// tailInterval is how often Tail checks for new log output.
+var tailInterval = 250 * time.Millisecond
+
+// Tail writes the log for the running sync with name to w as it's written. Stream must be either stdout or stderr.
+// Tail returns when the sync finishes or ctx is done. If the sync isn't running ErrSyncNotRunning is returned.
+func (re *Resync) Tail(ctx context.Context, name string, stream string, w io.Writer) error {
+	var open func(string) (io.ReadCloser, error)
+	switch stream {
+	case "stdout":
+		open = re.logger.Stdout
+	case "stderr":
+		open = re.logger.Stderr
+	default:
+		return fmt.Errorf("Invalid log stream: %s", stream)
+	}
+
+	if _, err := re.config.GetSync(name); err != nil {
+		return err
+	}
+
+	// subscribe before checking if the sync is running so the finishing event can't be missed
+	events, unsubscribe := re.Subscribe()
+	defer unsubscribe()
+
+	running, err := re.Running()
+	if err != nil {
+		return err
+	}
+
+	if !contains(running, name) {
+		return ErrSyncNotRunning
+	}
+
+	// the logs are rotated after the sync starts running so wait for them instead of following the last run's logs
+	rotated, err := re.request(re.rotatedc, name)
+	if err != nil {
+		return err
+	}
+
+	for !rotated {
+		select {
+		case event := <-events:
+			if event.Name != name {
+				continue
+			}
+
+			if event.Type == EventStarted {
+				rotated = true
+			} else if event.finished() {
+				return nil
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	f, err := open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ticker := time.NewTicker(tailInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := io.Copy(w, f); err != nil {
+			return err
+		}
+
+		select {
+		case event := <-events:
+			if event.Name == name && event.finished() {
+				// copy anything written after the last read
+				_, err := io.Copy(w, f)
+				return err
+			}
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
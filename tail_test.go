@@ -0,0 +1,162 @@
+package resync
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTailConfig returns a config with a single sync named test that runs testdata/output.sh.
+func newTailConfig() *Config {
+	return &Config{
+		RsyncPath: String("./testdata/output.sh"),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-a"),
+				RsyncSource:      []string{"0"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+		},
+	}
+}
+
+func TestTail(t *testing.T) {
+	re, cleanup := newTestResync(t, newTailConfig())
+	defer cleanup()
+
+	err := re.Tail(context.Background(), "test", "stdout", &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrSyncNotRunning)
+
+	err = re.Tail(context.Background(), "test", "foo", &bytes.Buffer{})
+	assert.Error(t, err)
+
+	err = re.Tail(context.Background(), "foo", "stdout", &bytes.Buffer{})
+	assert.Error(t, err)
+
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+
+	// give the sync time to rotate its logs
+	time.Sleep(100 * time.Millisecond)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- re.Tail(context.Background(), "test", "stderr", stderr)
+	}()
+
+	err = re.Tail(context.Background(), "test", "stdout", stdout)
+	assert.Nil(t, err)
+	assert.Nil(t, <-errc)
+	assert.Equal(t, "one\nthree\n", stdout.String())
+	assert.Equal(t, "two\n", stderr.String())
+}
+
+func TestTailContext(t *testing.T) {
+	re, cleanup := newTestResync(t, newTailConfig())
+	defer cleanup()
+
+	_, err := re.Run("test")
+	assert.Nil(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	stdout := &bytes.Buffer{}
+	err = re.Tail(ctx, "test", "stdout", stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "one\n", stdout.String())
+}
+
+func TestTailQueued(t *testing.T) {
+	re, cleanup := newTestResync(t, newTailConfig())
+	defer cleanup()
+	re.config.Syncs["test"].Overlap = String(OverlapQueue)
+
+	_, err := re.Run("test")
+	assert.Nil(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	stdout := &bytes.Buffer{}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- re.Tail(context.Background(), "test", "stdout", stdout)
+	}()
+
+	// queuing another run doesn't end the tail of the running one
+	time.Sleep(100 * time.Millisecond)
+	started, err := re.Run("test")
+	assert.Nil(t, err)
+	assert.True(t, started)
+
+	assert.Nil(t, <-errc)
+	assert.Equal(t, "one\nthree\n", stdout.String())
+}
+
+// slowLogger delays rotating the logs so that Tail is called between a sync starting and its logs being rotated.
+type slowLogger struct {
+	Logger
+}
+
+func (l slowLogger) Rotate(name string) (io.WriteCloser, io.WriteCloser, error) {
+	time.Sleep(300 * time.Millisecond)
+	return l.Logger.Rotate(name)
+}
+
+func TestTailRotate(t *testing.T) {
+	dir, err := os.MkdirTemp("", "resync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// each run prints how many times the sync has run
+	count := filepath.Join(dir, "count")
+	config := &Config{
+		RsyncPath: String("sh"),
+		LogPath:   String(dir),
+		LibPath:   String(dir),
+		Syncs: map[string]*Sync{
+			"test": {
+				RsyncArgs:        String("-c"),
+				RsyncSource:      []string{"n=$(cat " + count + " 2>/dev/null || echo 0); n=$((n+1)); echo $n > " + count + "; echo run $n; sleep 0.3"},
+				RsyncDestination: String("0"),
+				Schedule:         String("0 0 1 1 *"),
+			},
+		},
+	}
+	err = config.validate()
+	assert.Nil(t, err)
+
+	db, err := NewBoltDB(config)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	logger := slowLogger{NewFSLogger(config)}
+	re := New(config, db, logger, NewEmailNotifier(config, db, logger))
+
+	err = re.Start()
+	assert.Nil(t, err)
+	defer re.Stop()
+
+	err = re.sync("test")
+	assert.Nil(t, err)
+
+	// the second run is tailed before its logs are rotated
+	_, err = re.Run("test")
+	assert.Nil(t, err)
+
+	stdout := &bytes.Buffer{}
+	err = re.Tail(context.Background(), "test", "stdout", stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "run 2\n", stdout.String())
+}
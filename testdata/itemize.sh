@@ -0,0 +1,12 @@
+#!/bin/sh
+echo "sending incremental file list"
+echo "*deleting   data/x"
+echo "cd+++++++++ data/new/"
+echo ">f+++++++++ data/new/file"
+echo ">f.st...... data/changed"
+echo ".f...p..... data/mode"
+echo "cL+++++++++ data/link -> file"
+echo ".d..t...... data/"
+echo ""
+echo "sent 1,200 bytes  received 35 bytes  2,470.00 bytes/sec"
+echo "total size is 2,048  speedup is 1.66"
@@ -0,0 +1,6 @@
+#!/bin/sh
+echo one
+sleep 0.5
+echo two >&2
+sleep 0.5
+echo three
@@ -0,0 +1,5 @@
+#!/bin/sh
+printf "              0   0%%    0.00kB/s    0:00:00  \r"
+printf "      1,048,576  45%%    1.00MB/s    0:01:23 (xfr#1, to-chk=10/20)"
+sleep 1
+printf "\r      2,097,152 100%%    1.00MB/s    0:00:02 (xfr#2, to-chk=0/20)\n"
@@ -0,0 +1,14 @@
+#!/bin/sh
+echo "sending incremental file list"
+echo "test"
+echo ""
+echo "Number of files: 2 (reg: 1, dir: 1)"
+echo "Number of created files: 1 (reg: 1)"
+echo "Number of regular files transferred: 1"
+echo "Total file size: 2,048 bytes"
+echo "Total transferred file size: 1,024 bytes"
+echo "Literal data: 1,000 bytes"
+echo "Matched data: 24 bytes"
+echo ""
+echo "sent 1,200 bytes  received 35 bytes  2,470.00 bytes/sec"
+printf "total size is 2,048  speedup is 1.66"
@@ -0,0 +1,126 @@
+package resync
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes that's formatted using binary units when printed.
+type ByteSize int64
+
+func (b ByteSize) String() string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", int64(b))
+	}
+
+	div, exp := int64(unit), 0
+	for n := int64(b) / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// TransferStats are the totals printed by rsync when rsync_args contains --stats. Sizes are in bytes. Numbers
+// printed with a human readable suffix such as 1.5M are converted using units of 1000 so they're approximate.
+type TransferStats struct {
+	FilesConsidered  int64
+	FilesTransferred int64
+	TotalSize        ByteSize
+	TransferredSize  ByteSize
+	LiteralData      ByteSize
+	MatchedData      ByteSize
+	Speedup          float64
+}
+
+// hasStatsArg returns true if args tells rsync to print transfer stats.
+func hasStatsArg(args []string) bool {
+	return contains(args, "--stats")
+}
+
+// parseLine sets the field matching a single line of rsync --stats output. Lines that don't match a field are
+// ignored.
+func (t *TransferStats) parseLine(line string) {
+	line = strings.TrimSpace(line)
+
+	// the speedup is on the last line of the summary e.g. "total size is 1,024  speedup is 2.00"
+	if i := strings.Index(line, "speedup is "); i >= 0 {
+		fields := strings.Fields(line[i+len("speedup is "):])
+		if len(fields) > 0 {
+			if v, err := strconv.ParseFloat(strings.ReplaceAll(fields[0], ",", ""), 64); err == nil {
+				t.Speedup = v
+			}
+		}
+		return
+	}
+
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return
+	}
+
+	fields := strings.Fields(line[i+1:])
+	if len(fields) == 0 {
+		return
+	}
+
+	v, ok := parseNumber(fields[0])
+	if !ok {
+		return
+	}
+
+	switch line[:i] {
+	case "Number of files":
+		t.FilesConsidered = v
+	// older versions of rsync don't count only regular files
+	case "Number of regular files transferred", "Number of files transferred":
+		t.FilesTransferred = v
+	case "Total file size":
+		t.TotalSize = ByteSize(v)
+	case "Total transferred file size":
+		t.TransferredSize = ByteSize(v)
+	case "Literal data":
+		t.LiteralData = ByteSize(v)
+	case "Matched data":
+		t.MatchedData = ByteSize(v)
+	}
+}
+
+// parseNumber parses a number printed by rsync which may contain separators or a human readable suffix.
+func parseNumber(s string) (int64, bool) {
+	s = strings.ReplaceAll(s, ",", "")
+
+	multiplier := 1.0
+	if len(s) > 0 {
+		switch s[len(s)-1] {
+		case 'K', 'k':
+			multiplier = 1e3
+		case 'M', 'm':
+			multiplier = 1e6
+		case 'G', 'g':
+			multiplier = 1e9
+		case 'T', 't':
+			multiplier = 1e12
+		case 'P', 'p':
+			multiplier = 1e15
+		}
+
+		if multiplier != 1 {
+			s = s[:len(s)-1]
+		}
+	}
+
+	if multiplier == 1 {
+		v, err := strconv.ParseInt(s, 10, 64)
+		return v, err == nil
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64(v * multiplier), true
+}
@@ -0,0 +1,72 @@
+package resync
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const statsOutput = `
+Number of files: 1,234 (reg: 1,000, dir: 234)
+Number of created files: 2 (reg: 2)
+Number of deleted files: 0
+Number of regular files transferred: 5
+Total file size: 1,234,567 bytes
+Total transferred file size: 12,345 bytes
+Literal data: 12,000 bytes
+Matched data: 345 bytes
+File list size: 0
+File list generation time: 0.001 seconds
+File list transfer time: 0.000 seconds
+Total bytes sent: 13,000
+Total bytes received: 200
+
+sent 13,000 bytes  received 200 bytes  26,400.00 bytes/sec
+total size is 1,234,567  speedup is 93.53
+`
+
+func TestTransferStats(t *testing.T) {
+	stats := TransferStats{}
+	for _, line := range strings.Split(statsOutput, "\n") {
+		stats.parseLine(line)
+	}
+
+	assert.Equal(t, TransferStats{
+		FilesConsidered:  1234,
+		FilesTransferred: 5,
+		TotalSize:        1234567,
+		TransferredSize:  12345,
+		LiteralData:      12000,
+		MatchedData:      345,
+		Speedup:          93.53,
+	}, stats)
+}
+
+func TestTransferStatsHumanReadable(t *testing.T) {
+	stats := TransferStats{}
+	stats.parseLine("Number of files transferred: 3")
+	stats.parseLine("Total file size: 1.50M bytes")
+	stats.parseLine("Literal data: 2K bytes")
+	stats.parseLine("total size is 1.50M  speedup is 1,000.00 (DRY RUN)")
+	stats.parseLine("Matched data: invalid bytes")
+
+	assert.Equal(t, int64(3), stats.FilesTransferred)
+	assert.Equal(t, ByteSize(1500000), stats.TotalSize)
+	assert.Equal(t, ByteSize(2000), stats.LiteralData)
+	assert.Equal(t, ByteSize(0), stats.MatchedData)
+	assert.Equal(t, 1000.0, stats.Speedup)
+}
+
+func TestHasStatsArg(t *testing.T) {
+	assert.True(t, hasStatsArg([]string{"-a", "--stats", "/a", "/b"}))
+	assert.False(t, hasStatsArg([]string{"-a", "/a", "/b"}))
+}
+
+func TestByteSize(t *testing.T) {
+	assert.Equal(t, "0 B", ByteSize(0).String())
+	assert.Equal(t, "1023 B", ByteSize(1023).String())
+	assert.Equal(t, "1.0 KiB", ByteSize(1024).String())
+	assert.Equal(t, "1.5 MiB", ByteSize(1536*1024).String())
+	assert.Equal(t, "2.0 GiB", ByteSize(2<<30).String())
+}
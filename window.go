@@ -0,0 +1,149 @@
+package resync
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Actions taken when a sync is running as its window closes.
+const (
+	// WindowCloseFinish lets the running sync finish.
+	WindowCloseFinish = "finish"
+
+	// WindowCloseCancel cancels the running sync.
+	WindowCloseCancel = "cancel"
+)
+
+// windowCheckInterval is how often the main loop checks for windows that closed while syncs were running.
+const windowCheckInterval = time.Second
+
+// weekdays maps the short and long names of each day to its time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// Window is a time of day range on some days of the week. Start and End are local times formatted as 15:04. If End
+// is before Start then the window ends on the next day and Days are the days the window starts on. If End equals
+// Start then the window lasts the whole day.
+type Window struct {
+	// Days are the days of the week the window applies to. Days can be names like mon or monday and ranges like
+	// mon-fri. Defaults to every day.
+	Days []string `yaml:"days"`
+
+	// Start is when the window opens.
+	Start *string `yaml:"start"`
+
+	// End is when the window closes.
+	End *string `yaml:"end"`
+
+	days  [7]bool
+	start time.Duration
+	end   time.Duration
+}
+
+// validate checks the window and parses its days and times.
+func (w *Window) validate() error {
+	if w.Start == nil || w.End == nil {
+		return fmt.Errorf("Window requires both a start and an end")
+	}
+
+	var err error
+	if w.start, err = parseTimeOfDay(StringValue(w.Start)); err != nil {
+		return err
+	}
+
+	if w.end, err = parseTimeOfDay(StringValue(w.End)); err != nil {
+		return err
+	}
+
+	w.days = [7]bool{}
+	if len(w.Days) == 0 {
+		for i := range w.days {
+			w.days[i] = true
+		}
+		return nil
+	}
+
+	for _, day := range w.Days {
+		first, last, err := parseDays(day)
+		if err != nil {
+			return err
+		}
+
+		// ranges like fri-mon wrap around the end of the week
+		for d := first; ; d = (d + 1) % 7 {
+			w.days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// Contains returns true if t falls inside the window.
+func (w *Window) Contains(t time.Time) bool {
+	// use the wall clock so days with daylight saving changes still line up
+	tod := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	today := w.days[t.Weekday()]
+	yesterday := w.days[(t.Weekday()+6)%7]
+
+	switch {
+	case w.start == w.end:
+		return today
+	case w.start < w.end:
+		return today && tod >= w.start && tod < w.end
+	default:
+		// the window started yesterday and ends today or starts today and ends tomorrow
+		return (today && tod >= w.start) || (yesterday && tod < w.end)
+	}
+}
+
+// parseTimeOfDay parses a time formatted as 15:04 into the duration since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid window time %s: must be formatted as 15:04", s)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// parseDays parses a day name or a range of day names like mon-fri.
+func parseDays(s string) (time.Weekday, time.Weekday, error) {
+	names := strings.SplitN(strings.ToLower(strings.TrimSpace(s)), "-", 2)
+
+	first, ok := weekdays[strings.TrimSpace(names[0])]
+	if !ok {
+		return 0, 0, fmt.Errorf("Invalid window day: %s", s)
+	}
+
+	if len(names) == 1 {
+		return first, first, nil
+	}
+
+	last, ok := weekdays[strings.TrimSpace(names[1])]
+	if !ok {
+		return 0, 0, fmt.Errorf("Invalid window day: %s", s)
+	}
+
+	return first, last, nil
+}
+
+// inWindows returns true if t is inside any of windows.
+func inWindows(windows []*Window, t time.Time) bool {
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
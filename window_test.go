@@ -0,0 +1,67 @@
+package resync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindow(t *testing.T) {
+	// Mon Jan 01 2024
+	monday := func(hour, min int) time.Time {
+		return time.Date(2024, 1, 1, hour, min, 0, 0, time.Local)
+	}
+
+	business := &Window{Days: []string{"mon-fri"}, Start: String("09:00"), End: String("17:00")}
+	assert.Nil(t, business.validate())
+	assert.False(t, business.Contains(monday(8, 59)))
+	assert.True(t, business.Contains(monday(9, 0)))
+	assert.True(t, business.Contains(monday(16, 59)))
+	assert.False(t, business.Contains(monday(17, 0)))
+	assert.False(t, business.Contains(monday(12, 0).AddDate(0, 0, -1)))
+	assert.True(t, business.Contains(monday(12, 0).AddDate(0, 0, 4)))
+	assert.False(t, business.Contains(monday(12, 0).AddDate(0, 0, 5)))
+
+	// overnight windows belong to the day they start on
+	overnight := &Window{Days: []string{"Sunday"}, Start: String("22:00"), End: String("06:00")}
+	assert.Nil(t, overnight.validate())
+	assert.True(t, overnight.Contains(monday(5, 59)))
+	assert.False(t, overnight.Contains(monday(6, 0)))
+	assert.False(t, overnight.Contains(monday(23, 0)))
+	assert.True(t, overnight.Contains(monday(23, 0).AddDate(0, 0, -1)))
+	assert.False(t, overnight.Contains(monday(5, 0).AddDate(0, 0, -1)))
+
+	allDay := &Window{Days: []string{"sat", "sun"}, Start: String("00:00"), End: String("00:00")}
+	assert.Nil(t, allDay.validate())
+	assert.False(t, allDay.Contains(monday(12, 0)))
+	assert.True(t, allDay.Contains(monday(0, 0).AddDate(0, 0, -2)))
+	assert.True(t, allDay.Contains(monday(23, 59).AddDate(0, 0, -1)))
+
+	// ranges wrap around the end of the week and no days means every day
+	weekend := &Window{Days: []string{"fri-mon"}, Start: String("00:00"), End: String("00:00")}
+	assert.Nil(t, weekend.validate())
+	assert.True(t, weekend.Contains(monday(12, 0)))
+	assert.False(t, weekend.Contains(monday(12, 0).AddDate(0, 0, 1)))
+
+	everyDay := &Window{Start: String("01:00"), End: String("02:00")}
+	assert.Nil(t, everyDay.validate())
+	for i := 0; i < 7; i++ {
+		assert.True(t, everyDay.Contains(monday(1, 30).AddDate(0, 0, i)))
+	}
+}
+
+func TestWindowValidate(t *testing.T) {
+	invalid := []*Window{
+		{Start: String("09:00")},
+		{End: String("09:00")},
+		{Start: String("9am"), End: String("17:00")},
+		{Start: String("09:00"), End: String("25:00")},
+		{Days: []string{"someday"}, Start: String("09:00"), End: String("17:00")},
+		{Days: []string{"mon-someday"}, Start: String("09:00"), End: String("17:00")},
+	}
+
+	for _, w := range invalid {
+		assert.Error(t, w.validate())
+	}
+}
@@ -0,0 +1,71 @@
+package resync
+
+import (
+	"bytes"
+	"io"
+)
+
+// lineWriter is an io.Writer that calls fn for each line written to it. Lines end with either \n or \r because
+// rsync uses \r to update progress in place. Empty lines are skipped. If partial is set then it's called after each
+// write with anything written after the last line ending because rsync doesn't end a progress update until the next
+// one starts.
+type lineWriter struct {
+	fn      func(line string)
+	partial func(line string)
+	buf     []byte
+}
+
+func newLineWriter(fn func(line string)) *lineWriter {
+	return &lineWriter{
+		fn: fn,
+	}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexAny(w.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+
+		if i > 0 {
+			w.fn(string(w.buf[:i]))
+		}
+		w.buf = w.buf[i+1:]
+	}
+
+	if w.partial != nil && len(w.buf) > 0 {
+		w.partial(string(w.buf))
+	}
+
+	return len(p), nil
+}
+
+// Flush calls fn with anything written after the last line ending.
+func (w *lineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.fn(string(w.buf))
+	}
+	w.buf = nil
+}
+
+// multiWriter is like io.MultiWriter except nil writers are ignored. It returns nil if every writer is nil.
+func multiWriter(writers ...io.Writer) io.Writer {
+	nonNil := make([]io.Writer, 0, len(writers))
+	for _, w := range writers {
+		if w != nil {
+			nonNil = append(nonNil, w)
+		}
+	}
+
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	default:
+		return io.MultiWriter(nonNil...)
+	}
+}
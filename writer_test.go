@@ -0,0 +1,55 @@
+package resync
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineWriter(t *testing.T) {
+	lines := make([]string, 0)
+	w := newLineWriter(func(line string) {
+		lines = append(lines, line)
+	})
+
+	w.Write([]byte("one\ntw"))
+	assert.Equal(t, []string{"one"}, lines)
+
+	w.Write([]byte("o\r\nthree\r10%\r20%"))
+	assert.Equal(t, []string{"one", "two", "three", "10%"}, lines)
+
+	w.Flush()
+	assert.Equal(t, []string{"one", "two", "three", "10%", "20%"}, lines)
+
+	w.Flush()
+	assert.Len(t, lines, 5)
+}
+
+func TestLineWriterPartial(t *testing.T) {
+	lines := make([]string, 0)
+	partials := make([]string, 0)
+	w := newLineWriter(func(line string) {
+		lines = append(lines, line)
+	})
+	w.partial = func(line string) {
+		partials = append(partials, line)
+	}
+
+	w.Write([]byte("\r 10%"))
+	w.Write([]byte("\r 20%\n"))
+	assert.Equal(t, []string{" 10%", " 20%"}, lines)
+	assert.Equal(t, []string{" 10%"}, partials)
+}
+
+func TestMultiWriter(t *testing.T) {
+	assert.Nil(t, multiWriter(nil, nil))
+
+	a := &bytes.Buffer{}
+	assert.Equal(t, a, multiWriter(nil, a))
+
+	b := &bytes.Buffer{}
+	w := multiWriter(a, nil, b)
+	w.Write([]byte("test"))
+	assert.Equal(t, "testtest", a.String()+b.String())
+}